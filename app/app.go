@@ -1,61 +1,157 @@
 package app
 
 import (
+	"context"
+	"expvar"
 	"github.com/gin-gonic/gin"
-	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"net/http/pprof"
 	"project/app/handler"
 	"project/app/handler/pkg/ginvalidator"
 	"project/app/pkg/config"
+	"project/app/pkg/health"
+	"project/app/pkg/upgrade"
 )
 
 type App struct {
-	isDebug       config.IsDebug
-	httpAddresses []string // http 监听地址
+	isDebug              config.IsDebug
+	listenerConfigs      ListenerConfigs      // http 监听地址及 TLS 配置
+	adminListenerConfigs AdminListenerConfigs // 管理接口独立监听地址，为空时管理接口与业务接口共用监听地址
+	serverConfig         *ServerConfig        // http.Server 配置
+	zapLogger            *zap.Logger          // http 服务、监听地址相关日志，logger 名称：server
+	upgradeLogger        *zap.Logger          // 不停机升级相关日志，logger 名称：upgrade
+	healthRegistry       *health.Registry     // 健康检查注册表
+	upgraded             int32                // 是否已完成不停机升级（由新进程接管 listener）
 
-	loggerMiddleware   *handler.LoggerMiddleware   // http 日志中间件
-	recoveryMiddleware *handler.RecoveryMiddleware // recovery 中间件
+	requestIdMiddleware  *handler.RequestIdMiddleware  // 请求 ID 中间件
+	tracingMiddleware    *handler.TracingMiddleware    // 链路追踪中间件
+	loggerMiddleware     *handler.LoggerMiddleware     // http 日志中间件
+	metricsMiddleware    *handler.MetricsMiddleware    // 监控指标中间件
+	recoveryMiddleware   *handler.RecoveryMiddleware   // recovery 中间件
+	clientCertMiddleware *handler.ClientCertMiddleware // 客户端证书身份中间件
+	adminAuthMiddleware  *handler.AdminAuthMiddleware  // 管理接口鉴权中间件
 
-	loginSmsCtrl *handler.LoginSmsCtrl // 登录验证码控制器
-}
-
-type HttpAddresses []string
-
-func NewHttpAddresses(v *viper.Viper) HttpAddresses {
-	return v.GetStringSlice("addr")
+	loginSmsCtrl    *handler.LoginSmsCtrl    // 登录验证码控制器
+	logLevelCtrl    *handler.LogLevelCtrl    // 日志级别控制器（管理接口）
+	panicReportCtrl *handler.PanicReportCtrl // panic 报告控制器（管理接口）
+	healthCtrl      *handler.HealthCtrl      // 健康检查控制器
+	metricsCtrl     *handler.MetricsCtrl     // 监控指标控制器
+	debugCtrl       *handler.DebugCtrl       // 构建信息、配置、路由表控制器（管理接口）
+	configCtrl      *handler.ConfigCtrl      // 配置版本、热加载控制器（管理接口）
+	userCtrl        *handler.UserCtrl        // 用户控制器
 }
 
 func NewApp(
 	isDebug config.IsDebug,
-	httpAddresses HttpAddresses,
+	listenerConfigs ListenerConfigs,
+	adminListenerConfigs AdminListenerConfigs,
+	serverConfig *ServerConfig,
+	zapLogger *zap.Logger,
+	healthRegistry *health.Registry,
 
+	requestIdMiddleware *handler.RequestIdMiddleware,
+	tracingMiddleware *handler.TracingMiddleware,
 	loggerMiddleware *handler.LoggerMiddleware,
+	metricsMiddleware *handler.MetricsMiddleware,
 	recoveryMiddleware *handler.RecoveryMiddleware,
+	clientCertMiddleware *handler.ClientCertMiddleware,
+	adminAuthMiddleware *handler.AdminAuthMiddleware,
 
 	loginSmsCtrl *handler.LoginSmsCtrl,
+	logLevelCtrl *handler.LogLevelCtrl,
+	panicReportCtrl *handler.PanicReportCtrl,
+	healthCtrl *handler.HealthCtrl,
+	metricsCtrl *handler.MetricsCtrl,
+	debugCtrl *handler.DebugCtrl,
+	configCtrl *handler.ConfigCtrl,
+	userCtrl *handler.UserCtrl,
 ) *App {
 	return &App{
-		isDebug:            isDebug,
-		httpAddresses:      httpAddresses,
-		loggerMiddleware:   loggerMiddleware,
-		recoveryMiddleware: recoveryMiddleware,
-		loginSmsCtrl:       loginSmsCtrl,
+		isDebug:              isDebug,
+		listenerConfigs:      listenerConfigs,
+		adminListenerConfigs: adminListenerConfigs,
+		serverConfig:         serverConfig,
+		zapLogger:            zapLogger.Named("server"),
+		upgradeLogger:        zapLogger.Named("upgrade"),
+		healthRegistry:       healthRegistry,
+		requestIdMiddleware:  requestIdMiddleware,
+		tracingMiddleware:    tracingMiddleware,
+		loggerMiddleware:     loggerMiddleware,
+		metricsMiddleware:    metricsMiddleware,
+		recoveryMiddleware:   recoveryMiddleware,
+		clientCertMiddleware: clientCertMiddleware,
+		adminAuthMiddleware:  adminAuthMiddleware,
+		loginSmsCtrl:         loginSmsCtrl,
+		logLevelCtrl:         logLevelCtrl,
+		panicReportCtrl:      panicReportCtrl,
+		healthCtrl:           healthCtrl,
+		metricsCtrl:          metricsCtrl,
+		debugCtrl:            debugCtrl,
+		configCtrl:           configCtrl,
+		userCtrl:             userCtrl,
 	}
 }
 
+// Run 在所有 http 地址上启动服务，收到 SIGINT、SIGTERM 信号后优雅关闭。
+//
+// 收到 SIGHUP、SIGUSR2 信号时执行不停机升级：启动新进程并传递所有 listener，新进程就绪后当前进程优雅关闭。
 func (app *App) Run() error {
+	engine, adminEngine, err := app.newEngines()
+	if err != nil {
+		return err
+	}
+	// 业务接口、管理接口监听地址一同监听，以便一同继承、传递 listener
+	configs := make([]ListenerConfig, 0, len(app.listenerConfigs)+len(app.adminListenerConfigs))
+	configs = append(append(configs, app.listenerConfigs...), app.adminListenerConfigs...)
+	listeners, err := listen(configs, app.zapLogger)
+	if err != nil {
+		return err
+	}
+	for i := len(app.listenerConfigs); i < len(listeners); i++ {
+		listeners[i].admin = true
+	}
+
+	ctx, stop := signalContext(shutdownSignals...)
+	defer stop()
+	ctx, shutdown := context.WithCancel(ctx)
+	defer shutdown()
+	go app.watchUpgrade(ctx, shutdown, listeners)
+
+	// 由父进程不停机升级启动时，通知父进程已就绪
+	if err := upgrade.Ready(); err != nil {
+		app.upgradeLogger.Error("notify upgrade ready failed", zap.Error(err))
+	}
+	return app.serve(ctx, engine, adminEngine, listeners)
+}
+
+// newEngines 实例化业务接口、管理接口的 gin.Engine 并注册中间件、路由。
+//
+// 未配置管理接口独立监听地址（adminAddr）时，管理接口（/admin/*）注册在业务接口 gin.Engine 中，adminEngine 为 nil；
+// pprof、expvar 等调试接口仅在管理接口独立监听地址上提供。
+func (app *App) newEngines() (engine, adminEngine *gin.Engine, err error) {
 	if !app.isDebug {
 		gin.SetMode(gin.ReleaseMode)
 	}
 	if err := ginvalidator.Init(); err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	engine := gin.New()
+	engine = gin.New()
 	r := engine.Use(
+		app.requestIdMiddleware.CreateGinHandler(),
+		app.tracingMiddleware.CreateGinHandler(),
 		app.loggerMiddleware.CreateGinHandler(),
+		app.metricsMiddleware.CreateGinHandler(),
 		app.recoveryMiddleware.CreateGinHandler(),
+		app.clientCertMiddleware.CreateGinHandler(),
 	)
 
+	// 健康检查（供负载均衡器、容器编排系统探测）
+	engine.GET("/healthz", app.healthCtrl.Liveness)
+	engine.GET("/readyz", app.healthCtrl.Readiness)
+	// 监控指标（供 Prometheus 采集）
+	engine.GET("/metrics", app.metricsCtrl.Get)
+
 	// 手机验证码发送模块（聚合所有的手机验证码发送操作）
 	r = engine.Group("/sms")
 	{
@@ -63,5 +159,89 @@ func (app *App) Run() error {
 		r.POST("/login", app.loginSmsCtrl.Send)
 	}
 
-	return engine.Run(app.httpAddresses...)
+	// 用户（标准 CRUD 例子）
+	r = engine.Group("/users")
+	{
+		r.GET("", app.userCtrl.List)
+		r.GET("/:id", app.userCtrl.Get)
+		r.POST("", app.userCtrl.Create)
+		r.PATCH("/:id", app.userCtrl.Update)
+		r.DELETE("/:id", app.userCtrl.Delete)
+	}
+
+	if len(app.adminListenerConfigs) == 0 {
+		// 管理接口（仅允许携带管理令牌的请求访问）
+		app.registerAdminRoutes(engine.Group("/admin", app.adminAuthMiddleware.CreateGinHandler()))
+		return engine, nil, nil
+	}
+
+	adminEngine = gin.New()
+	adminEngine.Use(
+		app.requestIdMiddleware.CreateGinHandler(),
+		app.loggerMiddleware.CreateGinHandler(),
+		app.recoveryMiddleware.CreateGinHandler(),
+		// 配置了管理令牌时校验令牌，否则仅允许本机访问
+		app.adminAuthMiddleware.CreateLoopbackGinHandler(),
+	)
+
+	// 管理接口
+	r = adminEngine.Group("/admin")
+	app.registerAdminRoutes(r)
+	{
+		// 查看构建信息
+		r.GET("/build", app.debugCtrl.BuildInfo)
+		// 查看生效中的配置（机密配置项已脱敏）
+		r.GET("/config", app.debugCtrl.Config)
+		// 查看业务接口、管理接口路由表
+		r.GET("/routes", app.debugCtrl.Routes(map[string]*gin.Engine{"public": engine, "admin": adminEngine}))
+	}
+
+	// pprof 性能分析
+	r = adminEngine.Group("/debug/pprof")
+	{
+		r.GET("/", gin.WrapF(pprof.Index))
+		r.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+		r.GET("/profile", gin.WrapF(pprof.Profile))
+		r.GET("/symbol", gin.WrapF(pprof.Symbol))
+		r.POST("/symbol", gin.WrapF(pprof.Symbol))
+		r.GET("/trace", gin.WrapF(pprof.Trace))
+		for _, name := range []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"} {
+			r.GET("/"+name, gin.WrapH(pprof.Handler(name)))
+		}
+	}
+	// expvar 运行时变量
+	adminEngine.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+
+	return engine, adminEngine, nil
+}
+
+// Routes 返回业务接口（public）、管理接口（admin，未配置 adminAddr 时与业务接口共用）的路由表
+func (app *App) Routes() (map[string]gin.RoutesInfo, error) {
+	engine, adminEngine, err := app.newEngines()
+	if err != nil {
+		return nil, err
+	}
+	routes := map[string]gin.RoutesInfo{"public": engine.Routes()}
+	if adminEngine != nil {
+		routes["admin"] = adminEngine.Routes()
+	}
+	return routes, nil
+}
+
+// registerAdminRoutes 注册管理接口路由
+func (app *App) registerAdminRoutes(r gin.IRoutes) {
+	// 查看、调整日志级别，可用的 logger 名称见 handler.LogLevelCtrl.Put
+	r.GET("/log/level", app.logLevelCtrl.Get)
+	r.PUT("/log/level", app.logLevelCtrl.Put)
+	// 移除指定 logger 名称的日志级别覆盖
+	r.DELETE("/log/level/:logger", app.logLevelCtrl.Delete)
+	// 浏览 panic 报告
+	r.GET("/panics", app.panicReportCtrl.List)
+	r.GET("/panics/:fingerprint", app.panicReportCtrl.Get)
+	// 查看健康检查详细结果
+	r.GET("/health", app.healthCtrl.Detail)
+	// 查看生效中的配置版本
+	r.GET("/config/version", app.configCtrl.Version)
+	// 重新加载配置文件
+	r.POST("/config/reload", app.configCtrl.Reload)
 }
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"github.com/spf13/viper"
+	"project/app/handler"
+	"project/app/pkg/config"
+	"project/app/pkg/db"
+	"project/app/pkg/health"
+	"project/app/pkg/logger"
+	"project/app/pkg/metrics"
+	"project/app/pkg/pagination"
+	"project/app/pkg/panicreport"
+	"project/app/pkg/redact"
+	"project/app/pkg/sms"
+	"project/app/pkg/tracing"
+	"project/app/service"
+)
+
+// configSchema 为所有顶层配置节点，启动时据此校验配置，新增配置节点时须在此登记
+var configSchema = config.Schema{
+	"isDebug": func(v *viper.Viper) error {
+		var isDebug bool
+		return config.Unmarshal(v, "isDebug", &isDebug)
+	},
+	"addr": func(v *viper.Viper) error {
+		_, err := NewListenerConfigs(v)
+		return err
+	},
+	"adminAddr": func(v *viper.Viper) error {
+		adminConfig, err := handler.NewAdminConfig(v)
+		if err != nil {
+			// admin 节点的问题由 admin 单独报告
+			adminConfig = &handler.AdminConfig{}
+		}
+		_, err = NewAdminListenerConfigs(v, adminConfig)
+		return err
+	},
+	"server": func(v *viper.Viper) error {
+		_, err := NewServerConfig(v)
+		return err
+	},
+	"health": func(v *viper.Viper) error {
+		_, err := health.NewConfig(v)
+		return err
+	},
+	"tracing": func(v *viper.Viper) error {
+		_, err := tracing.NewConfig(v)
+		return err
+	},
+	"metrics": func(v *viper.Viper) error {
+		_, err := metrics.NewConfig(v)
+		return err
+	},
+	"admin": func(v *viper.Viper) error {
+		_, err := handler.NewAdminConfig(v)
+		return err
+	},
+	"log": func(v *viper.Viper) error {
+		var problems []string
+		for _, err := range []error{
+			errorOf(logger.NewConfig(config.NewIsDebug(v), v)),
+			errorOf(handler.NewBodyCaptureConfig(v)),
+			errorOf(redact.NewRedactor(v)),
+		} {
+			problems = append(problems, config.Problems(err)...)
+		}
+		if len(problems) > 0 {
+			return &config.ValidationError{Problems: problems}
+		}
+		return nil
+	},
+	"panicReport": func(v *viper.Viper) error {
+		_, err := panicreport.NewConfig(v)
+		return err
+	},
+	"aliyunLoginSms": func(v *viper.Viper) error {
+		_, err := sms.NewAliyunConfig(v)
+		return err
+	},
+	"loginSms": func(v *viper.Viper) error {
+		_, err := service.NewLoginSmsConfig(v)
+		return err
+	},
+	"db": func(v *viper.Viper) error {
+		_, err := db.NewConfig(v)
+		return err
+	},
+	"pagination": func(v *viper.Viper) error {
+		_, err := pagination.NewConfig(v)
+		return err
+	},
+	"reload": func(v *viper.Viper) error {
+		_, err := config.NewReloadConfig(v)
+		return err
+	},
+}
+
+// NewConfigSchema 返回 configSchema，供配置热加载时校验新配置
+func NewConfigSchema() config.Schema {
+	return configSchema
+}
+
+// errorOf 丢弃 provider 的返回值，仅保留错误
+func errorOf(_ interface{}, err error) error {
+	return err
+}
+
+// NewViper 读取配置，并在实例化其它组件前按 configSchema 校验所有配置，一次性列出所有问题
+func NewViper(overrides config.Overrides, configFiles ...config.FilePath) (*viper.Viper, error) {
+	v, err := config.NewViper(overrides, configFiles...)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.Validate(v, configSchema); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,24 @@
+package app
+
+import (
+	"github.com/stretchr/testify/assert"
+	"project/app/pkg/config"
+	"testing"
+)
+
+// TestConfigSchema 配置模板、机密配置模板中的所有配置项均须在 configSchema 中登记并通过校验
+func TestConfigSchema(t *testing.T) {
+	a := assert.New(t)
+	_, err := NewViper(nil, "./config/template.yaml", "./config/secret.yaml")
+	a.Nil(err)
+
+	_, err = NewViper(
+		config.Overrides{"unknown=1", "server.shutdownTimeout=0s", "aliyunLoginSms.accessKeyID="},
+		"./config/template.yaml",
+	)
+	a.Equal([]string{
+		"unknown config key `unknown`",
+		"`aliyunLoginSms.accessKeyId` is required",
+		"`server.shutdownTimeout` must be greater than 0, got 0s",
+	}, config.Problems(err))
+}
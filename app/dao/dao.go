@@ -0,0 +1,32 @@
+// 本包为 dao 层：封装数据库读写，每张表对应一个 dao（文件命名统一使用 dao 前缀）
+
+package dao
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"project/app/pkg/db"
+)
+
+// ErrNotFound 表示记录不存在
+var ErrNotFound = errors.New("record not found")
+
+// DuplicateError 表示违反唯一约束，例：用户名已被使用
+type DuplicateError struct {
+	// 违反唯一约束的字段（列名），例：username
+	Field string
+	err   error
+}
+
+func (err *DuplicateError) Error() string {
+	return fmt.Sprintf("duplicate %s: %s", err.Field, err.err)
+}
+
+// convertError 将违反唯一约束的错误转换为 *DuplicateError，其它错误原样返回
+func convertError(err error) error {
+	column, ok := db.UniqueViolation(err)
+	if !ok {
+		return err
+	}
+	return &DuplicateError{Field: column, err: err}
+}
@@ -0,0 +1,163 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pkg/errors"
+	"project/app/model"
+	"project/app/pkg/filter"
+	"strings"
+	"time"
+)
+
+// userColumns 为查询用户时的所有列，与 scanUser 一致
+const userColumns = "id, username, display_name, email, create_time, update_time"
+
+// UserSchema 为用户列表允许在 filter、order_by 中使用的字段
+var UserSchema = filter.NewSchema("id",
+	filter.Field{Name: "id", Column: "id", Type: filter.TypeInt, Sortable: true},
+	filter.Field{Name: "username", Column: "username", Type: filter.TypeString, Sortable: true},
+	filter.Field{Name: "display_name", Column: "display_name", Type: filter.TypeString, Sortable: true},
+	filter.Field{Name: "email", Column: "email", Type: filter.TypeString, Sortable: true},
+	filter.Field{Name: "create_time", Column: "create_time", Type: filter.TypeTimestamp, Sortable: true},
+	filter.Field{Name: "update_time", Column: "update_time", Type: filter.TypeTimestamp, Sortable: true},
+)
+
+type UserDao struct {
+	db *sql.DB
+}
+
+func NewUserDao(db *sql.DB) *UserDao {
+	return &UserDao{db: db}
+}
+
+// List 查询符合 f（为 nil 时不过滤）的用户，按 orderBy 排序，最多返回 limit 个；
+// after 不为 nil 时，仅返回排在排序键 after（see: filter.OrderBy.DecodeKeys()）之后的用户
+func (dao *UserDao) List(ctx context.Context, f *filter.Filter, orderBy filter.OrderBy, after []interface{}, limit int) ([]*model.User, error) {
+	conditions := make([]string, 0, 2)
+	args := make([]interface{}, 0)
+	if where, whereArgs := f.SQL(); where != "" {
+		conditions = append(conditions, where)
+		args = append(args, whereArgs...)
+	}
+	if after != nil {
+		where, whereArgs := orderBy.After(after)
+		conditions = append(conditions, where)
+		args = append(args, whereArgs...)
+	}
+	query := "SELECT " + userColumns + " FROM users"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY " + orderBy.SQL() + " LIMIT ?"
+	rows, err := dao.db.QueryContext(ctx, query, append(args, limit)...)
+	if err != nil {
+		return nil, errors.Wrap(err, "query users failed")
+	}
+	defer rows.Close()
+	users := make([]*model.User, 0)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "query users failed")
+	}
+	return users, nil
+}
+
+// Get 查询用户，不存在时返回 ErrNotFound
+func (dao *UserDao) Get(ctx context.Context, id int64) (*model.User, error) {
+	row := dao.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = ?", id)
+	return scanUser(row)
+}
+
+// Create 创建用户，并回填 Id、CreateTime、UpdateTime；用户名、邮箱已被使用时返回 *DuplicateError
+func (dao *UserDao) Create(ctx context.Context, user *model.User) error {
+	now := time.Now().UTC()
+	result, err := dao.db.ExecContext(ctx,
+		"INSERT INTO users (username, display_name, email, create_time, update_time) VALUES (?, ?, ?, ?, ?)",
+		user.Username, user.DisplayName, user.Email, now, now,
+	)
+	if err != nil {
+		return errors.Wrap(convertError(err), "insert user failed")
+	}
+	if user.Id, err = result.LastInsertId(); err != nil {
+		return errors.Wrap(err, "get user id failed")
+	}
+	user.CreateTime, user.UpdateTime = now, now
+	return nil
+}
+
+// Update 更新用户 fields 中的字段（json 字段名称：username、display_name、email），并回填 UpdateTime；
+// 仅写入 fields 对应的列，并发更新不同字段时互不覆盖。
+// 用户不存在时返回 ErrNotFound，用户名、邮箱已被使用时返回 *DuplicateError
+func (dao *UserDao) Update(ctx context.Context, user *model.User, fields []string) error {
+	now := time.Now().UTC()
+	sets := make([]string, 0, len(fields)+1)
+	args := make([]interface{}, 0, len(fields)+2)
+	for _, field := range fields {
+		switch field {
+		case "username":
+			sets, args = append(sets, "username = ?"), append(args, user.Username)
+		case "display_name":
+			sets, args = append(sets, "display_name = ?"), append(args, user.DisplayName)
+		case "email":
+			sets, args = append(sets, "email = ?"), append(args, user.Email)
+		default:
+			return errors.Errorf("user field `%s` is not updatable", field)
+		}
+	}
+	sets, args = append(sets, "update_time = ?"), append(args, now, user.Id)
+	result, err := dao.db.ExecContext(ctx, "UPDATE users SET "+strings.Join(sets, ", ")+" WHERE id = ?", args...)
+	if err != nil {
+		return errors.Wrap(convertError(err), "update user failed")
+	}
+	if err := mustAffected(result); err != nil {
+		return err
+	}
+	user.UpdateTime = now
+	return nil
+}
+
+// Delete 删除用户，不存在时返回 ErrNotFound
+func (dao *UserDao) Delete(ctx context.Context, id int64) error {
+	result, err := dao.db.ExecContext(ctx, "DELETE FROM users WHERE id = ?", id)
+	if err != nil {
+		return errors.Wrap(err, "delete user failed")
+	}
+	return mustAffected(result)
+}
+
+// scanner 为 *sql.Row、*sql.Rows 共有的 Scan 方法
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanUser 读取一行用户记录，不存在时返回 ErrNotFound
+func scanUser(row scanner) (*model.User, error) {
+	var user model.User
+	err := row.Scan(&user.Id, &user.Username, &user.DisplayName, &user.Email, &user.CreateTime, &user.UpdateTime)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "scan user failed")
+	}
+	return &user, nil
+}
+
+// mustAffected 检查 UPDATE、DELETE 语句是否影响了记录，未影响时返回 ErrNotFound
+func mustAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "get rows affected failed")
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
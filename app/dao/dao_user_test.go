@@ -0,0 +1,82 @@
+package dao_test
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"project/app/dao"
+	"project/app/model"
+	"project/app/test/helper"
+	"testing"
+)
+
+func TestUserDao(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+	userDao := dao.NewUserDao(helper.NewTestDB(t))
+
+	alice := &model.User{Username: "alice", DisplayName: "Alice", Email: "alice@example.com"}
+	a.Nil(userDao.Create(ctx, alice))
+	a.Equal(int64(1), alice.Id)
+	a.False(alice.CreateTime.IsZero())
+	bob := &model.User{Username: "bob", Email: "bob@example.com"}
+	a.Nil(userDao.Create(ctx, bob))
+
+	user, err := userDao.Get(ctx, alice.Id)
+	a.Nil(err)
+	a.Equal("Alice", user.DisplayName)
+	a.True(alice.CreateTime.Equal(user.CreateTime))
+	orderBy, err := dao.UserSchema.ParseOrderBy("")
+	a.Nil(err)
+	users, err := userDao.List(ctx, nil, orderBy, nil, 10)
+	a.Nil(err)
+	a.Len(users, 2)
+	a.Equal("bob", users[1].Username)
+	users, err = userDao.List(ctx, nil, orderBy, []interface{}{alice.Id}, 1)
+	a.Nil(err)
+	a.Len(users, 1)
+	a.Equal("bob", users[0].Username)
+	f, err := dao.UserSchema.ParseFilter(`email:"ALICE@" AND NOT display_name = ""`)
+	a.Nil(err)
+	orderBy, err = dao.UserSchema.ParseOrderBy("username desc")
+	a.Nil(err)
+	users, err = userDao.List(ctx, f, orderBy, nil, 10)
+	a.Nil(err)
+	a.Len(users, 1)
+	a.Equal("alice", users[0].Username)
+
+	// 唯一约束不区分大小写
+	err = userDao.Create(ctx, &model.User{Username: "ALICE", Email: "other@example.com"})
+	duplicate, ok := errors.Cause(err).(*dao.DuplicateError)
+	a.True(ok)
+	a.Equal("username", duplicate.Field)
+	bob.Email = "Alice@Example.com"
+	err = userDao.Update(ctx, bob, []string{"email"})
+	duplicate, ok = errors.Cause(err).(*dao.DuplicateError)
+	a.True(ok)
+	a.Equal("email", duplicate.Field)
+
+	bob.Email = "bob@example.org"
+	a.Nil(userDao.Update(ctx, bob, []string{"email"}))
+	user, err = userDao.Get(ctx, bob.Id)
+	a.Nil(err)
+	a.Equal("bob@example.org", user.Email)
+
+	// 仅更新指定的列：基于旧数据的两次部分更新互不覆盖
+	stale := *user
+	user.DisplayName = "Bob"
+	a.Nil(userDao.Update(ctx, user, []string{"display_name"}))
+	stale.Email = "bob@example.net"
+	a.Nil(userDao.Update(ctx, &stale, []string{"email"}))
+	user, err = userDao.Get(ctx, bob.Id)
+	a.Nil(err)
+	a.Equal("Bob", user.DisplayName)
+	a.Equal("bob@example.net", user.Email)
+	a.NotNil(userDao.Update(ctx, user, []string{"id"}))
+
+	a.Nil(userDao.Delete(ctx, bob.Id))
+	_, err = userDao.Get(ctx, bob.Id)
+	a.Equal(dao.ErrNotFound, err)
+	a.Equal(dao.ErrNotFound, userDao.Delete(ctx, bob.Id))
+	a.Equal(dao.ErrNotFound, userDao.Update(ctx, bob, []string{"email"}))
+}
@@ -0,0 +1,24 @@
+package dao
+
+import "project/app/pkg/db"
+
+// migrations 为所有数据库迁移，新增迁移时追加到末尾，版本号递增；已发布的迁移不得修改
+var migrations = db.Migrations{
+	{
+		Version: 1,
+		Name:    "create_users",
+		Up: `CREATE TABLE users (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	username     TEXT NOT NULL UNIQUE COLLATE NOCASE,
+	display_name TEXT NOT NULL DEFAULT '',
+	email        TEXT NOT NULL UNIQUE COLLATE NOCASE,
+	create_time  TIMESTAMP NOT NULL,
+	update_time  TIMESTAMP NOT NULL
+)`,
+	},
+}
+
+// NewMigrations 返回所有数据库迁移
+func NewMigrations() db.Migrations {
+	return migrations
+}
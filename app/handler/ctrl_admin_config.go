@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"project/app/handler/pkg/e"
+	"project/app/pkg/config"
+)
+
+// ConfigCtrl 查看生效中的配置版本、触发配置热加载（管理接口）
+type ConfigCtrl struct {
+	reloader *config.Reloader
+}
+
+func NewConfigCtrl(reloader *config.Reloader) *ConfigCtrl {
+	return &ConfigCtrl{reloader: reloader}
+}
+
+// Version 查看生效中的配置版本，以及最近一次重新加载失败的原因
+func (ctrl *ConfigCtrl) Version(c *gin.Context) {
+	success(c, ctrl.reloader.Status())
+}
+
+// Reload 重新加载配置文件：配置文件未变更时不做任何处理；新配置校验失败时继续使用原配置，并返回所有问题
+func (ctrl *ConfigCtrl) Reload(c *gin.Context) {
+	if _, err := ctrl.reloader.Reload(); err != nil {
+		violations := make([]*e.PreconditionFailureViolation, 0)
+		for _, problem := range config.Problems(err) {
+			violations = append(violations, &e.PreconditionFailureViolation{
+				Type:        "CONFIG",
+				Subject:     "config",
+				Description: problem,
+			})
+		}
+		fail(c, err, e.CodeFailedPrecondition, &e.PreconditionFailure{Violations: violations})
+		return
+	}
+	ctrl.Version(c)
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"project/app/pkg/config"
+	"project/app/test/helper"
+	"testing"
+)
+
+func TestConfigCtrl(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "config")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	a.Nil(ioutil.WriteFile(file, []byte("log: {level: info}\n"), 0600))
+
+	paths := []config.FilePath{config.FilePath(file)}
+	v, err := config.NewViper(nil, paths...)
+	a.Nil(err)
+	schema := config.Schema{
+		"log": func(v *viper.Viper) error {
+			var cfg struct {
+				Level string `mapstructure:"level" validate:"oneof=debug info"`
+			}
+			return config.Unmarshal(v, "log", &cfg)
+		},
+	}
+	reloader, err := config.NewReloader(v, nil, paths, schema, zap.NewNop())
+	a.Nil(err)
+	ctrl := NewConfigCtrl(reloader)
+
+	engine := gin.New()
+	engine.GET("/admin/config/version", ctrl.Version)
+	engine.POST("/admin/config/reload", ctrl.Reload)
+	expect := helper.NewHttpExcept(t, engine)
+
+	expect.GET("/admin/config/version").Expect().Status(http.StatusOK).
+		JSON().Object().Path("$.data.current.version").Equal(1)
+
+	a.Nil(ioutil.WriteFile(file, []byte("log: {level: debug}\n"), 0600))
+	data := expect.POST("/admin/config/reload").Expect().Status(http.StatusOK).
+		JSON().Object().Value("data").Object()
+	data.Path("$.current.version").Equal(2)
+	data.Path("$.current.changed").Array().Elements("log.level")
+
+	// 校验失败时继续使用原配置，并列出所有问题
+	a.Nil(ioutil.WriteFile(file, []byte("log: {level: trace}\nunknown: 1\n"), 0600))
+	expect.POST("/admin/config/reload").Expect().Status(http.StatusBadRequest).
+		JSON().Object().Path("$.error[0].violations").Array().Length().Equal(2)
+	data = expect.GET("/admin/config/version").Expect().Status(http.StatusOK).
+		JSON().Object().Value("data").Object()
+	data.Path("$.current.version").Equal(2)
+	data.Value("last_error").String().Contains("unknown config key `unknown`")
+}
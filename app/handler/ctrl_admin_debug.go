@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"project/app/pkg/buildinfo"
+	"project/app/pkg/config"
+	"project/app/pkg/redact"
+	"sort"
+)
+
+// DebugCtrl 查看构建信息、生效中的配置、路由表（管理接口）
+type DebugCtrl struct {
+	reloader *config.Reloader
+	redactor *redact.Redactor
+}
+
+func NewDebugCtrl(reloader *config.Reloader, redactor *redact.Redactor) *DebugCtrl {
+	return &DebugCtrl{reloader: reloader, redactor: redactor}
+}
+
+// BuildInfo 查看构建信息：版本号、VCS 修订版本、构建时间、Go 版本
+func (ctrl *DebugCtrl) BuildInfo(c *gin.Context) {
+	success(c, buildinfo.Get())
+}
+
+// Config 查看生效中的配置（合并所有配置文件后，含热加载的变更），机密配置项已脱敏
+func (ctrl *DebugCtrl) Config(c *gin.Context) {
+	success(c, ctrl.redactor.Settings(ctrl.reloader.Current().Viper.AllSettings()))
+}
+
+// Routes 生成查看路由表的 gin.HandlerFunc，参数 engines 的 key 为监听地址名称（例：public、admin）
+func (ctrl *DebugCtrl) Routes(engines map[string]*gin.Engine) gin.HandlerFunc {
+	type Route struct {
+		Listener string `json:"listener"`
+		Method   string `json:"method"`
+		Path     string `json:"path"`
+		Handler  string `json:"handler"`
+	}
+	return func(c *gin.Context) {
+		routes := make([]Route, 0)
+		for name, engine := range engines {
+			for _, route := range engine.Routes() {
+				routes = append(routes, Route{
+					Listener: name,
+					Method:   route.Method,
+					Path:     route.Path,
+					Handler:  route.Handler,
+				})
+			}
+		}
+		sort.Slice(routes, func(i, j int) bool {
+			if routes[i].Listener != routes[j].Listener {
+				return routes[i].Listener < routes[j].Listener
+			}
+			if routes[i].Path != routes[j].Path {
+				return routes[i].Path < routes[j].Path
+			}
+			return routes[i].Method < routes[j].Method
+		})
+		success(c, routes)
+	}
+}
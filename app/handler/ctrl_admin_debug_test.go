@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"project/app/pkg/config"
+	"project/app/pkg/redact"
+	"project/app/test/helper"
+	"testing"
+)
+
+func TestDebugCtrl(t *testing.T) {
+	a := assert.New(t)
+	v := viper.New()
+	v.Set("addr", []string{":80"})
+	v.Set("aliyunLoginSms.accessKeySecret", "secret-value")
+	redactor, err := redact.New(redact.DefaultConfig)
+	a.Nil(err)
+	reloader, err := config.NewReloader(v, nil, nil, nil, zap.NewNop())
+	a.Nil(err)
+	ctrl := NewDebugCtrl(reloader, redactor)
+
+	public := gin.New()
+	public.POST("/sms/login", func(c *gin.Context) {})
+	engine := gin.New()
+	r := engine.Group("/admin", NewAdminAuthMiddleware(&AdminConfig{}).CreateLoopbackGinHandler())
+	r.GET("/build", ctrl.BuildInfo)
+	r.GET("/config", ctrl.Config)
+	r.GET("/routes", ctrl.Routes(map[string]*gin.Engine{"public": public, "admin": engine}))
+
+	// 未配置管理令牌时，仅允许本机连接访问
+	expect := helper.NewHttpExcept(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(context.WithValue(r.Context(), http.LocalAddrContextKey,
+			&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 9090}))
+		engine.ServeHTTP(w, r)
+	}))
+	expect.GET("/admin/build").Expect().Status(http.StatusOK).
+		JSON().Object().Path("$.data.go_version").String().NotEmpty()
+	expect.GET("/admin/config").Expect().Status(http.StatusOK).
+		JSON().Object().Path("$.data.aliyunloginsms.accesskeysecret").Equal("***")
+	routes := expect.GET("/admin/routes").Expect().Status(http.StatusOK).
+		JSON().Object().Path("$.data").Array()
+	routes.Length().Equal(4)
+	routes.Element(0).Object().ValueEqual("listener", "admin").ValueEqual("path", "/admin/build")
+	routes.Element(3).Object().ValueEqual("listener", "public").ValueEqual("path", "/sms/login").
+		ValueEqual("method", http.MethodPost)
+
+	// 非本机连接
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/admin/build", nil)
+	req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey,
+		&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 9090}))
+	engine.ServeHTTP(w, req)
+	a.Equal(http.StatusForbidden, w.Code)
+}
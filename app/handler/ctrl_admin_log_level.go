@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"project/app/handler/pkg/e"
+	"project/app/pkg/logger"
+	"time"
+)
+
+// LogLevelCtrl 运行时查看、调整日志级别（管理接口）
+type LogLevelCtrl struct {
+	levelController *logger.LevelController
+}
+
+func NewLogLevelCtrl(levelController *logger.LevelController) *LogLevelCtrl {
+	return &LogLevelCtrl{levelController: levelController}
+}
+
+// Get 查看当前生效的全局日志级别，以及所有按 logger 名称覆盖的日志级别
+func (ctrl *LogLevelCtrl) Get(c *gin.Context) {
+	base, loggers := ctrl.levelController.Status()
+	success(c, gin.H{
+		"level":     base.Level,
+		"expire_at": base.ExpireAt,
+		"loggers":   loggers,
+	})
+}
+
+// Put 调整日志级别
+//
+// 参数 logger 为空时调整全局日志级别，否则覆盖指定 logger 名称的日志级别；
+// 参数 duration 不为空时为限时调整（如：`10m`），到期后自动恢复。
+//
+// 可用的 logger 名称：
+//   - http：http 日志（LoggerMiddleware）
+//   - recovery：panic 日志（RecoveryMiddleware）
+//   - panicreport：panic 报告告警
+//   - sms：短信验证码发送
+//   - server：http 服务启动、停止及监听地址；server.tls：TLS 证书重新加载
+//   - upgrade：不停机升级
+//   - config：配置热加载
+func (ctrl *LogLevelCtrl) Put(c *gin.Context) {
+	type Form struct {
+		Level    string `form:"level" json:"level" binding:"required,oneof=debug info warn error dpanic panic fatal"`
+		Logger   string `form:"logger" json:"logger"`
+		Duration string `form:"duration" json:"duration"`
+	}
+	var form Form
+	if !mustBind(c, &form) {
+		return
+	}
+
+	level, err := logger.ParseLevel(form.Level)
+	if err != nil {
+		fail(c, err, e.CodeInternal)
+		return
+	}
+	var duration time.Duration
+	if form.Duration != "" {
+		if duration, err = time.ParseDuration(form.Duration); err != nil || duration <= 0 {
+			fail(c, errors.Errorf("invalid duration `%s`", form.Duration), e.CodeInvalidArgument,
+				&e.BadRequest{FieldViolations: []*e.BadRequestFieldViolation{
+					{Field: "duration", Description: "duration必须是有效的正时长，如：10m"},
+				}},
+			)
+			return
+		}
+	}
+
+	if form.Logger == "" {
+		ctrl.levelController.SetLevel(level, duration)
+	} else {
+		ctrl.levelController.SetLoggerLevel(form.Logger, level, duration)
+	}
+	ctrl.Get(c)
+}
+
+// Delete 移除指定 logger 名称的日志级别覆盖
+func (ctrl *LogLevelCtrl) Delete(c *gin.Context) {
+	name := c.Param("logger")
+	if !ctrl.levelController.RemoveLoggerLevel(name) {
+		fail(c, errors.Errorf("logger level override `%s` not found", name), e.CodeNotFound,
+			&e.ResourceInfo{ResourceType: "logger", ResourceName: name},
+		)
+		return
+	}
+	ctrl.Get(c)
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"net/http"
+	"project/app/handler/pkg/e"
+	"project/app/pkg/logger"
+	"project/app/test/helper"
+	"testing"
+)
+
+func TestLogLevelCtrl(t *testing.T) {
+	a := assert.New(t)
+	levelController, err := logger.NewLevelController(&logger.Config{Level: "info"})
+	a.Nil(err)
+	ctrl := NewLogLevelCtrl(levelController)
+
+	engine := gin.New()
+	r := engine.Group("/admin", NewAdminAuthMiddleware(&AdminConfig{Token: "secret"}).CreateGinHandler())
+	r.GET("/log/level", ctrl.Get)
+	r.PUT("/log/level", ctrl.Put)
+	r.DELETE("/log/level/:logger", ctrl.Delete)
+
+	expect := helper.NewHttpExcept(t, engine)
+	expect.GET("/admin/log/level").
+		Expect().Status(http.StatusUnauthorized)
+	expect.GET("/admin/log/level").WithHeader("Authorization", "Bearer wrong").
+		Expect().Status(http.StatusUnauthorized)
+	// 必须使用 Bearer 认证方案
+	expect.GET("/admin/log/level").WithHeader("Authorization", "secret").
+		Expect().Status(http.StatusUnauthorized)
+	expect.GET("/admin/log/level").WithHeader("Authorization", "Basic secret").
+		Expect().Status(http.StatusUnauthorized)
+	expect.GET("/admin/log/level").WithHeader("Authorization", "bearer secret").
+		Expect().Status(http.StatusOK)
+
+	auth := "Bearer secret"
+	expect.GET("/admin/log/level").WithHeader("Authorization", auth).
+		Expect().Status(http.StatusOK).
+		JSON().Object().Path("$.data.level").Equal("info")
+
+	expect.PUT("/admin/log/level").WithHeader("Authorization", auth).
+		WithJSON(map[string]string{"level": "debug", "duration": "1h"}).
+		Expect().Status(http.StatusOK).
+		JSON().Object().Path("$.data.expire_at").NotNull()
+	a.Equal(zapcore.DebugLevel, levelController.AtomicLevel().Level())
+
+	expect.PUT("/admin/log/level").WithHeader("Authorization", auth).
+		WithJSON(map[string]string{"level": "warn", "logger": "sms"}).
+		Expect().Status(http.StatusOK).
+		JSON().Object().Path("$.data.loggers.sms.level").Equal("warn")
+
+	expect.PUT("/admin/log/level").WithHeader("Authorization", auth).
+		WithJSON(map[string]string{"level": "verbose"}).
+		Expect().Status(http.StatusBadRequest).
+		JSON().Object().ValueEqual("code", e.CodeInvalidArgument)
+	expect.PUT("/admin/log/level").WithHeader("Authorization", auth).
+		WithJSON(map[string]string{"level": "info", "duration": "-1m"}).
+		Expect().Status(http.StatusBadRequest)
+
+	expect.DELETE("/admin/log/level/sms").WithHeader("Authorization", auth).
+		Expect().Status(http.StatusOK)
+	expect.DELETE("/admin/log/level/sms").WithHeader("Authorization", auth).
+		Expect().Status(http.StatusNotFound)
+}
+
+func TestAdminAuthMiddleware_TokenNotConfigured(t *testing.T) {
+	engine := gin.New()
+	engine.GET("/admin", NewAdminAuthMiddleware(&AdminConfig{}).CreateGinHandler(), func(c *gin.Context) {
+		success(c, nil)
+	})
+	helper.NewHttpExcept(t, engine).GET("/admin").WithHeader("Authorization", "Bearer ").
+		Expect().Status(http.StatusForbidden)
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"project/app/handler/pkg/e"
+	"project/app/pkg/panicreport"
+)
+
+// PanicReportCtrl 浏览 panic 汇总报告（管理接口）
+type PanicReportCtrl struct {
+	recorder *panicreport.Recorder
+}
+
+func NewPanicReportCtrl(recorder *panicreport.Recorder) *PanicReportCtrl {
+	return &PanicReportCtrl{recorder: recorder}
+}
+
+// List 列出所有 panic 报告，按最近一次发生时间倒序排列
+func (ctrl *PanicReportCtrl) List(c *gin.Context) {
+	success(c, gin.H{"reports": ctrl.recorder.List()})
+}
+
+// Get 查看指定指纹的 panic 报告
+func (ctrl *PanicReportCtrl) Get(c *gin.Context) {
+	fingerprint := c.Param("fingerprint")
+	report, ok := ctrl.recorder.Get(fingerprint)
+	if !ok {
+		fail(c, errors.Errorf("panic report `%s` not found", fingerprint), e.CodeNotFound,
+			&e.ResourceInfo{ResourceType: "panic_report", ResourceName: fingerprint},
+		)
+		return
+	}
+	success(c, report)
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"project/app/handler/pkg/e"
+	"project/app/pkg/health"
+	"strings"
+)
+
+// HealthCtrl 健康检查，供负载均衡器、容器编排系统探测服务状态
+type HealthCtrl struct {
+	registry *health.Registry
+}
+
+func NewHealthCtrl(registry *health.Registry) *HealthCtrl {
+	return &HealthCtrl{registry: registry}
+}
+
+// Liveness 存活检查：进程能够处理请求即视为存活，不检查依赖组件，避免依赖故障导致进程被反复重启
+func (ctrl *HealthCtrl) Liveness(c *gin.Context) {
+	success(c, gin.H{"status": health.StatusUp})
+}
+
+// Readiness 就绪检查：服务正在优雅关闭，或任一非可选检查失败时，响应 e.CodeUnavailable
+func (ctrl *HealthCtrl) Readiness(c *gin.Context) {
+	report, ready := ctrl.registry.Check(c.Request.Context())
+	if !ready {
+		// 失败的检查项仅记录在日志中，不返回给客户端
+		var failures []string
+		for _, result := range report.Checks {
+			if result.Status != health.StatusUp && !result.Optional {
+				failures = append(failures, result.Name+": "+result.Error)
+			}
+		}
+		fail(c, errors.Errorf("service not ready, status: %s, failures: [%s]", report.Status, strings.Join(failures, "; ")),
+			e.CodeUnavailable)
+		return
+	}
+	success(c, gin.H{"status": report.Status})
+}
+
+// Detail 查看各项检查的详细结果（管理接口）
+func (ctrl *HealthCtrl) Detail(c *gin.Context) {
+	report, _ := ctrl.registry.Check(c.Request.Context())
+	success(c, report)
+}
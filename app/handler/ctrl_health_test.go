@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"net/http"
+	"project/app/pkg/health"
+	"project/app/test/helper"
+	"testing"
+	"time"
+)
+
+func TestHealthCtrl(t *testing.T) {
+	registry := health.NewRegistry(&health.Config{Timeout: time.Second})
+	var cacheErr error
+	registry.Register(health.Check{Name: "cache", Func: func(ctx context.Context) error { return cacheErr }})
+	registry.Register(health.Check{
+		Name:     "sms",
+		Func:     func(ctx context.Context) error { return errors.New("sms unavailable") },
+		Optional: true,
+	})
+	ctrl := NewHealthCtrl(registry)
+
+	engine := gin.New()
+	engine.GET("/healthz", ctrl.Liveness)
+	engine.GET("/readyz", ctrl.Readiness)
+	engine.GET("/admin/health", ctrl.Detail)
+	expect := helper.NewHttpExcept(t, engine)
+
+	// 可选检查失败不影响就绪状态
+	expect.GET("/readyz").Expect().Status(http.StatusOK).
+		JSON().Object().Path("$.data.status").Equal(health.StatusUp)
+	checks := expect.GET("/admin/health").Expect().Status(http.StatusOK).
+		JSON().Object().Path("$.data.checks").Array()
+	checks.Length().Equal(2)
+	checks.Element(1).Object().ValueEqual("name", "sms").
+		ValueEqual("status", health.StatusDown).
+		ValueEqual("error", "sms unavailable")
+
+	// 非可选检查失败时未就绪，存活检查不受影响
+	cacheErr = errors.New("cache unavailable")
+	expect.GET("/readyz").Expect().Status(http.StatusServiceUnavailable)
+	expect.GET("/healthz").Expect().Status(http.StatusOK)
+	expect.GET("/admin/health").Expect().Status(http.StatusOK).
+		JSON().Object().Path("$.data.status").Equal(health.StatusDown)
+
+	// 优雅关闭期间未就绪
+	cacheErr = nil
+	registry.SetShuttingDown()
+	expect.GET("/readyz").Expect().Status(http.StatusServiceUnavailable)
+	expect.GET("/healthz").Expect().Status(http.StatusOK)
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+// MetricsCtrl 以 Prometheus 文本格式暴露监控指标
+type MetricsCtrl struct {
+	handler http.Handler
+}
+
+func NewMetricsCtrl(registry *prometheus.Registry) *MetricsCtrl {
+	return &MetricsCtrl{handler: promhttp.HandlerFor(registry, promhttp.HandlerOpts{})}
+}
+
+// Get 查看监控指标
+func (ctrl *MetricsCtrl) Get(c *gin.Context) {
+	ctrl.handler.ServeHTTP(c.Writer, c.Request)
+}
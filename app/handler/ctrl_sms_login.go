@@ -28,7 +28,7 @@ func (ctrl *LoginSmsCtrl) Send(c *gin.Context) {
 	}
 
 	// 发送登录短信验证码
-	if err := ctrl.smsService.Send(form.CnCellPhoneNumber); err != nil {
+	if err := ctrl.smsService.Send(c.Request.Context(), form.CnCellPhoneNumber); err != nil {
 		if err, ok := err.(*service.SmsRequestOutOfLimitError); ok {
 			fail(c, err, e.CodeResourceExhausted,
 				&e.QuotaFailure{Violations: []*e.QuotaFailureViolation{
@@ -38,6 +38,7 @@ func (ctrl *LoginSmsCtrl) Send(c *gin.Context) {
 				}},
 				&e.RetryInfo{RetryDelay: err.RetryDelay},
 			)
+			return
 		}
 		fail(c, errors.Wrap(err, "发送登录验证码失败"), e.CodeInternal)
 		return
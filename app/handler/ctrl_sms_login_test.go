@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"net/http"
+	"project/app/handler/pkg/e"
+	"project/app/pkg/cache"
+	"project/app/pkg/metrics"
+	"project/app/service"
+	"project/app/test/helper"
+	"testing"
+	"time"
+)
+
+// fakeSender 记录发送的验证码，不实际发送短信
+type fakeSender struct {
+	codes []string
+}
+
+func (s *fakeSender) Send(ctx context.Context, cellPhoneNumber string, code string, expire int) error {
+	s.codes = append(s.codes, code)
+	return nil
+}
+
+func (s *fakeSender) Provider() string {
+	return "fake"
+}
+
+func TestLoginSmsCtrl_RateLimit(t *testing.T) {
+	a := assert.New(t)
+	smsMetrics, err := metrics.NewSmsMetrics(&metrics.Config{}, prometheus.NewRegistry())
+	a.Nil(err)
+	sender := &fakeSender{}
+	smsService := service.NewLoginSmsService(
+		&service.LoginSmsConfig{RateLimit: service.RateLimitConfig{Window: time.Minute, Max: 2}},
+		sender, cache.NewGoCache(), smsMetrics, trace.NewNoopTracerProvider(), zap.NewNop(),
+	)
+	engine := gin.New()
+	engine.POST("/sms/login", NewLoginSmsCtrl(smsService).Send)
+	expect := helper.NewHttpExcept(t, engine)
+
+	form := map[string]string{"cn_cell_phone_number": "13812341234"}
+	for i := 0; i < 2; i++ {
+		expect.POST("/sms/login").WithJSON(form).Expect().Status(http.StatusOK)
+	}
+	// 超出频率限制：仅响应一次 RESOURCE_EXHAUSTED
+	body := expect.POST("/sms/login").WithJSON(form).
+		Expect().Status(http.StatusTooManyRequests).JSON().Object()
+	body.ValueEqual("code", e.CodeResourceExhausted)
+	body.Path("$.error[0].violations[0].description").String().NotEmpty()
+	a.Len(sender.codes, 2)
+}
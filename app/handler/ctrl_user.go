@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"project/app/handler/pkg/e"
+	"project/app/model"
+	"project/app/pkg/filter"
+	"project/app/pkg/pagination"
+	"project/app/service"
+	"strconv"
+)
+
+// UserCtrl 用户资源的标准方法（List、Get、Create、Update、Delete），
+// 设计参照谷歌 API 设计指南，see: https://cloud.google.com/apis/design/standard_methods
+type UserCtrl struct {
+	userService service.IUser
+	paginator   *pagination.Paginator
+}
+
+func NewUserCtrl(userService service.IUser, paginator *pagination.Paginator) *UserCtrl {
+	return &UserCtrl{userService: userService, paginator: paginator}
+}
+
+// userForm 为创建、更新用户的请求参数
+type userForm struct {
+	Username    string `form:"username" json:"username" binding:"required,min=3,max=32,alphanum"`
+	DisplayName string `form:"display_name" json:"display_name" binding:"max=64"`
+	Email       string `form:"email" json:"email" binding:"required,email,max=254"`
+}
+
+// userUpdateForm 为更新用户的请求参数，仅校验、更新 update_mask 中的字段
+type userUpdateForm struct {
+	Username    string `form:"username" json:"username" binding:"required,min=3,max=32,alphanum"`
+	DisplayName string `form:"display_name" json:"display_name" binding:"max=64"`
+	Email       string `form:"email" json:"email" binding:"required,email,max=254"`
+	// 以 `,` 分隔的待更新字段，也可以通过 query 参数传递；为空时更新全部字段
+	UpdateMask string `form:"update_mask" json:"update_mask"`
+}
+
+// List 分页列出用户，支持 filter、order_by 参数，可用字段见 dao.UserSchema
+func (ctrl *UserCtrl) List(c *gin.Context) {
+	filterText, orderBy := c.Query(filter.ParamFilter), c.Query(filter.ParamOrderBy)
+	page, ok := mustPage(c, ctrl.paginator, listScope(model.UserResourceType, filterText, orderBy))
+	if !ok {
+		return
+	}
+	users, nextPageToken, err := ctrl.userService.List(c.Request.Context(), page, filterText, orderBy)
+	if err != nil {
+		failResource(c, err, "列出用户失败")
+		return
+	}
+	type Data struct {
+		Users []*model.User `json:"users"`
+		// 下一页的 page_token，没有下一页时为空字符串
+		NextPageToken string `json:"next_page_token"`
+	}
+	success(c, &Data{Users: users, NextPageToken: nextPageToken})
+}
+
+// Get 获取用户
+func (ctrl *UserCtrl) Get(c *gin.Context) {
+	id, ok := mustUserId(c)
+	if !ok {
+		return
+	}
+	user, err := ctrl.userService.Get(c.Request.Context(), id)
+	if err != nil {
+		failResource(c, err, "获取用户失败")
+		return
+	}
+	success(c, user)
+}
+
+// Create 创建用户，返回创建后的用户
+func (ctrl *UserCtrl) Create(c *gin.Context) {
+	var form userForm
+	if !mustBind(c, &form) {
+		return
+	}
+	user, err := ctrl.userService.Create(c.Request.Context(), &model.User{
+		Username:    form.Username,
+		DisplayName: form.DisplayName,
+		Email:       form.Email,
+	})
+	if err != nil {
+		failResource(c, err, "创建用户失败")
+		return
+	}
+	success(c, user)
+}
+
+// Update 更新用户 update_mask 中的字段，返回更新后的用户
+func (ctrl *UserCtrl) Update(c *gin.Context) {
+	id, ok := mustUserId(c)
+	if !ok {
+		return
+	}
+	var form userUpdateForm
+	mask, ok := mustBindMask(c, &form, &form.UpdateMask, &model.User{})
+	if !ok {
+		return
+	}
+	user, err := ctrl.userService.Update(c.Request.Context(), &model.User{
+		Id:          id,
+		Username:    form.Username,
+		DisplayName: form.DisplayName,
+		Email:       form.Email,
+	}, mask)
+	if err != nil {
+		failResource(c, err, "更新用户失败")
+		return
+	}
+	success(c, user)
+}
+
+// Delete 删除用户
+func (ctrl *UserCtrl) Delete(c *gin.Context) {
+	id, ok := mustUserId(c)
+	if !ok {
+		return
+	}
+	if err := ctrl.userService.Delete(c.Request.Context(), id); err != nil {
+		failResource(c, err, "删除用户失败")
+		return
+	}
+	success(c, nil)
+}
+
+// mustUserId 读取路径参数中的用户 id，不合法时响应 e.CodeInvalidArgument 错误
+func mustUserId(c *gin.Context) (id int64, ok bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		fail(c, errors.Errorf("invalid user id `%s`", c.Param("id")), e.CodeInvalidArgument,
+			&e.BadRequest{FieldViolations: []*e.BadRequestFieldViolation{
+				{Field: "id", Description: "id必须为正整数"},
+			}},
+		)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"project/app/dao"
+	"project/app/handler/pkg/e"
+	"project/app/pkg/pagination"
+	"project/app/service"
+	"project/app/test/helper"
+	"testing"
+)
+
+func TestUserCtrl(t *testing.T) {
+	paginator := pagination.NewPaginator(&pagination.Config{Secret: "secret", DefaultPageSize: 1, MaxPageSize: 10})
+	ctrl := NewUserCtrl(service.NewUserService(dao.NewUserDao(helper.NewTestDB(t))), paginator)
+	engine := gin.New()
+	engine.GET("/users", ctrl.List)
+	engine.GET("/users/:id", ctrl.Get)
+	engine.POST("/users", ctrl.Create)
+	engine.PATCH("/users/:id", ctrl.Update)
+	engine.DELETE("/users/:id", ctrl.Delete)
+	expect := helper.NewHttpExcept(t, engine)
+
+	// Create
+	user := expect.POST("/users").
+		WithJSON(map[string]string{"username": "alice", "display_name": "Alice", "email": "alice@example.com"}).
+		Expect().Status(http.StatusOK).JSON().Object().Value("data").Object()
+	user.ValueEqual("id", 1)
+	user.ValueEqual("username", "alice")
+	user.Value("create_time").String().NotEmpty()
+	expect.POST("/users").
+		WithJSON(map[string]string{"username": "bob", "email": "bob@example.com"}).
+		Expect().Status(http.StatusOK)
+
+	// 参数校验失败
+	expect.POST("/users").
+		WithJSON(map[string]string{"username": "a", "email": "not-an-email"}).
+		Expect().Status(http.StatusBadRequest).
+		JSON().Object().Path("$.error[0].field_violations").Array().Length().Equal(2)
+
+	// 用户名已被使用
+	body := expect.POST("/users").
+		WithJSON(map[string]string{"username": "Alice", "email": "alice2@example.com"}).
+		Expect().Status(http.StatusConflict).JSON().Object()
+	body.ValueEqual("code", e.CodeAlreadyExists)
+	body.Path("$.error[0].resource_type").Equal("user")
+
+	// List：默认每页 1 个
+	data := expect.GET("/users").Expect().Status(http.StatusOK).JSON().Object().Value("data").Object()
+	data.Path("$.users[0].username").Equal("alice")
+	pageToken := data.Value("next_page_token").String().NotEmpty().Raw()
+	data = expect.GET("/users").WithQuery("page_token", pageToken).
+		Expect().Status(http.StatusOK).JSON().Object().Value("data").Object()
+	data.Path("$.users[0].username").Equal("bob")
+	data.ValueEqual("next_page_token", "")
+	data = expect.GET("/users").WithQuery("page_size", 100).
+		Expect().Status(http.StatusOK).JSON().Object().Value("data").Object()
+	data.Value("users").Array().Length().Equal(2)
+	data.ValueEqual("next_page_token", "")
+	// 篡改的 page_token、不合法的 page_size
+	expect.GET("/users").WithQuery("page_token", "x"+pageToken).
+		Expect().Status(http.StatusBadRequest).
+		JSON().Object().Path("$.error[0].field_violations[0].field").Equal("page_token")
+	expect.GET("/users").WithQuery("page_size", "abc").
+		Expect().Status(http.StatusBadRequest).
+		JSON().Object().Path("$.error[0].field_violations[0].field").Equal("page_size")
+
+	// filter、order_by：按用户名倒序分页，page_token 不能用于不同的 filter、order_by
+	data = expect.GET("/users").WithQuery("order_by", "username desc").
+		Expect().Status(http.StatusOK).JSON().Object().Value("data").Object()
+	data.Path("$.users[0].username").Equal("bob")
+	pageToken = data.Value("next_page_token").String().NotEmpty().Raw()
+	expect.GET("/users").WithQuery("order_by", "username desc").WithQuery("page_token", pageToken).
+		Expect().Status(http.StatusOK).JSON().Object().Path("$.data.users[0].username").Equal("alice")
+	expect.GET("/users").WithQuery("page_token", pageToken).Expect().Status(http.StatusBadRequest)
+	expect.GET("/users").WithQuery("filter", `username = "bob" OR email:alice`).WithQuery("page_size", 10).
+		Expect().Status(http.StatusOK).JSON().Object().Path("$.data.users").Array().Length().Equal(2)
+	expect.GET("/users").WithQuery("filter", `create_time > "2000-01-01T00:00:00Z" AND NOT username:bob`).
+		Expect().Status(http.StatusOK).JSON().Object().Path("$.data.users[0].username").Equal("alice")
+	body = expect.GET("/users").WithQuery("filter", "username = bob AND phone = 1").
+		Expect().Status(http.StatusBadRequest).JSON().Object()
+	body.ValueEqual("code", e.CodeInvalidArgument)
+	body.Path("$.error[0].field_violations[0].field").Equal("filter")
+	body.Path("$.error[0].field_violations[0].description").String().Contains("第20个字符处的`phone`")
+	expect.GET("/users").WithQuery("order_by", "email asc, phone").
+		Expect().Status(http.StatusBadRequest).
+		JSON().Object().Path("$.error[0].field_violations[0].field").Equal("order_by")
+
+	// Get
+	expect.GET("/users/2").Expect().Status(http.StatusOK).
+		JSON().Object().Path("$.data.email").Equal("bob@example.com")
+	body = expect.GET("/users/3").Expect().Status(http.StatusNotFound).JSON().Object()
+	body.ValueEqual("code", e.CodeNotFound)
+	body.Path("$.error[0].resource_name").Equal("users/3")
+	expect.GET("/users/abc").Expect().Status(http.StatusBadRequest).
+		JSON().Object().Path("$.error[0].field_violations[0].field").Equal("id")
+
+	// Update
+	user = expect.PATCH("/users/2").
+		WithJSON(map[string]string{"username": "bob", "display_name": "Bob", "email": "bob@example.org"}).
+		Expect().Status(http.StatusOK).JSON().Object().Value("data").Object()
+	user.ValueEqual("display_name", "Bob")
+	user.ValueEqual("email", "bob@example.org")
+	expect.PATCH("/users/2").
+		WithJSON(map[string]string{"username": "bob", "email": "ALICE@example.com"}).
+		Expect().Status(http.StatusConflict)
+	expect.PATCH("/users/3").
+		WithJSON(map[string]string{"username": "carol", "email": "carol@example.com"}).
+		Expect().Status(http.StatusNotFound)
+
+	// update_mask：仅更新、校验其中的字段
+	user = expect.PATCH("/users/2").WithQuery("update_mask", "display_name").
+		WithJSON(map[string]string{"display_name": "Bobby", "email": "invalid"}).
+		Expect().Status(http.StatusOK).JSON().Object().Value("data").Object()
+	user.ValueEqual("display_name", "Bobby")
+	user.ValueEqual("email", "bob@example.org")
+	user = expect.PATCH("/users/2").
+		WithJSON(map[string]string{"email": "bob@example.net", "update_mask": "email"}).
+		Expect().Status(http.StatusOK).JSON().Object().Value("data").Object()
+	user.ValueEqual("username", "bob")
+	user.ValueEqual("email", "bob@example.net")
+	body = expect.PATCH("/users/2").WithQuery("update_mask", "display_name,email").
+		WithJSON(map[string]string{"email": "invalid"}).
+		Expect().Status(http.StatusBadRequest).JSON().Object()
+	body.Path("$.error[0].field_violations").Array().Length().Equal(1)
+	body.Path("$.error[0].field_violations[0].field").Equal("email")
+	body = expect.PATCH("/users/2").WithQuery("update_mask", "create_time").
+		WithJSON(map[string]string{}).
+		Expect().Status(http.StatusBadRequest).JSON().Object()
+	body.Path("$.error[0].field_violations[0].field").Equal("update_mask")
+	body.Path("$.error[0].field_violations[0].description").String().Contains("只读字段")
+
+	// Delete
+	expect.DELETE("/users/2").Expect().Status(http.StatusOK)
+	expect.DELETE("/users/2").Expect().Status(http.StatusNotFound)
+	expect.GET("/users").WithQuery("page_size", 10).Expect().Status(http.StatusOK).
+		JSON().Object().Path("$.data.users").Array().Length().Equal(1)
+}
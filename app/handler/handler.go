@@ -8,8 +8,17 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/pkg/errors"
 	"go.uber.org/zap/zapcore"
+	"net/url"
 	"project/app/handler/pkg/e"
 	"project/app/handler/pkg/ginvalidator"
+	"project/app/pkg/fieldmask"
+	"project/app/pkg/filter"
+	"project/app/pkg/pagination"
+	"project/app/pkg/principal"
+	"project/app/pkg/tracing"
+	"project/app/service"
+	"strconv"
+	"strings"
 )
 
 // body 即 response body
@@ -28,8 +37,27 @@ const (
 	contextKeyError = "error"
 	// gin.Context 中“日志级别”对应的 key，供日志中间件使用
 	contextKeyLogLevel = "logLevel"
+	// gin.Context 中“请求 ID”对应的 key
+	contextKeyRequestId = "requestId"
+	// gin.Context 中“调用方身份”对应的 key
+	contextKeyPrincipal = "principal"
 )
 
+// headerRequestId 为携带请求 ID 的 http header
+const headerRequestId = "X-Request-Id"
+
+// requestId 获取当前请求的请求 ID（由 RequestIdMiddleware 分配），不存在时返回空字符串
+func requestId(c *gin.Context) string {
+	return c.GetString(contextKeyRequestId)
+}
+
+// clientPrincipal 获取当前请求经客户端证书认证的调用方身份（由 ClientCertMiddleware 设置），不存在时返回 nil
+func clientPrincipal(c *gin.Context) *principal.Principal {
+	p, _ := c.Get(contextKeyPrincipal)
+	res, _ := p.(*principal.Principal)
+	return res
+}
+
 // logError 将请求过程中的 “错误信息” 和 “日志级别” 附到 gin.Context，供日志中间件使用。
 //
 // 该方法一般用于 success()、fail() 方法的间接调用。
@@ -54,7 +82,14 @@ func success(c *gin.Context, data interface{}) {
 }
 
 // fail 响应错误
+//
+// 当前请求存在请求 ID 或 trace id，且 errorDetails 中不包含 e.RequestInfo 时，自动附加 e.RequestInfo 错误详情
+// （trace id 记录在 ServingData 中），便于根据客户端反馈的请求 ID、trace id 查找日志及链路。
 func fail(c *gin.Context, err error, code e.Code, errorDetails ...e.IErrorDetail) {
+	id, traceId := requestId(c), tracing.TraceId(c.Request.Context())
+	if (id != "" || traceId != "") && !hasRequestInfo(errorDetails) {
+		errorDetails = append(errorDetails, &e.RequestInfo{RequestId: id, ServingData: traceId})
+	}
 	codeDetail := e.GetCodeDetail(code)
 	body := &body{
 		Code:    codeDetail.Code,
@@ -68,6 +103,85 @@ func fail(c *gin.Context, err error, code e.Code, errorDetails ...e.IErrorDetail
 	c.JSON(codeDetail.HttpStatus, body)
 }
 
+// failResource 响应资源操作错误：
+//
+// *pagination.Error、*filter.Error：响应 e.CodeInvalidArgument 错误，附加 e.BadRequest 错误详情
+// *service.NotFoundError：响应 e.CodeNotFound 错误，附加 e.ResourceInfo 错误详情
+// *service.AlreadyExistsError：响应 e.CodeAlreadyExists 错误，附加 e.ResourceInfo 错误详情
+// 其它错误：响应 e.CodeInternal 错误，日志中的错误信息附加 msg
+func failResource(c *gin.Context, err error, msg string) {
+	switch err := err.(type) {
+	case *pagination.Error:
+		failPagination(c, err)
+	case *filter.Error:
+		fail(c, err, e.CodeInvalidArgument, &e.BadRequest{FieldViolations: []*e.BadRequestFieldViolation{
+			{Field: err.Param, Description: err.Error()},
+		}})
+	case *service.NotFoundError:
+		fail(c, err, e.CodeNotFound, &e.ResourceInfo{
+			ResourceType: err.ResourceType,
+			ResourceName: err.ResourceName,
+			Description:  err.Error(),
+		})
+	case *service.AlreadyExistsError:
+		fail(c, err, e.CodeAlreadyExists, &e.ResourceInfo{
+			ResourceType: err.ResourceType,
+			Description:  err.Message,
+		})
+	default:
+		fail(c, errors.Wrap(err, msg), e.CodeInternal)
+	}
+}
+
+// mustPage 读取列表请求的 query 参数 page_size、page_token 并校验，参数 scope 见 pagination.Paginator.Parse()。
+//
+// 参数不合法时响应 e.CodeInvalidArgument 错误并返回 false
+func mustPage(c *gin.Context, paginator *pagination.Paginator, scope string) (page *pagination.Page, ok bool) {
+	var pageSize int
+	if value := c.Query(pagination.FieldPageSize); value != "" {
+		var err error
+		if pageSize, err = strconv.Atoi(value); err != nil {
+			failPagination(c, &pagination.Error{
+				Field:       pagination.FieldPageSize,
+				Description: pagination.FieldPageSize + "必须是一个整数",
+			})
+			return nil, false
+		}
+	}
+	page, err := paginator.Parse(pageSize, c.Query(pagination.FieldPageToken), scope)
+	if err != nil {
+		failPagination(c, err.(*pagination.Error))
+		return nil, false
+	}
+	return page, true
+}
+
+// listScope 返回列表请求 page_token 的适用范围：资源类型及 filter、order_by 参数，
+// 使 page_token 不能用于 filter、order_by 不同的请求
+func listScope(resourceType, filterText, orderBy string) string {
+	return resourceType + "?" + url.Values{
+		filter.ParamFilter:  {filterText},
+		filter.ParamOrderBy: {orderBy},
+	}.Encode()
+}
+
+// failPagination 响应分页参数错误
+func failPagination(c *gin.Context, err *pagination.Error) {
+	fail(c, err, e.CodeInvalidArgument, &e.BadRequest{FieldViolations: []*e.BadRequestFieldViolation{
+		{Field: err.Field, Description: err.Description},
+	}})
+}
+
+// hasRequestInfo 判断错误详情中是否已包含 e.RequestInfo
+func hasRequestInfo(errorDetails []e.IErrorDetail) bool {
+	for _, detail := range errorDetails {
+		if _, ok := detail.(*e.RequestInfo); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // mustBind 类同 c.Bind()，将 request 参数绑定到指定结构体，并进行校验。
 //
 // 绑定校验成功：返回 true
@@ -100,12 +214,58 @@ func mustBind(c *gin.Context, obj interface{}, replaces ...map[string]string) (s
 		fail(c, err, e.CodeInternal)
 		return false
 	}
+	failValidation(c, errs, replace)
+	return false
+}
+
+// mustBindMask 类同 mustBind()，用于资源的部分更新（PATCH）：将 request 参数绑定到 obj，读取 update_mask 并按资源 resource 校验，
+// 仅校验 update_mask 中的字段，见 project/app/pkg/fieldmask 包。
+//
+// update_mask 优先读取 query 参数，其次为 request body 中的 update_mask 字段，即：绑定后的 *updateMask；
+// 为空时表示完整替换，校验所有字段。
+//
+// update_mask 有误、字段校验失败时响应 e.CodeInvalidArgument 错误，并返回 false
+func mustBindMask(c *gin.Context, obj interface{}, updateMask *string, resource interface{}) (mask *fieldmask.Mask, success bool) {
+	err := c.ShouldBind(obj)
+	errs, ok := err.(validator.ValidationErrors)
+	if err != nil && !ok {
+		fail(c, err, e.CodeInternal)
+		return nil, false
+	}
+
+	text := c.Query(fieldmask.Param)
+	if text == "" {
+		text = *updateMask
+	}
+	if mask, err = fieldmask.Parse(text, resource, obj); err != nil {
+		fail(c, err, e.CodeInvalidArgument, &e.BadRequest{FieldViolations: []*e.BadRequestFieldViolation{
+			{Field: fieldmask.Param, Description: err.Error()},
+		}})
+		return nil, false
+	}
 
+	// 忽略 update_mask 以外字段的校验错误
+	masked := make(validator.ValidationErrors, 0, len(errs))
+	for _, fieldError := range errs {
+		namespace := fieldError.Namespace()
+		if mask.Contains(namespace[strings.Index(namespace, ".")+1:]) {
+			masked = append(masked, fieldError)
+		}
+	}
+	if len(masked) > 0 {
+		failValidation(c, masked, nil)
+		return nil, false
+	}
+	return mask, true
+}
+
+// failValidation 响应字段校验错误：e.CodeInvalidArgument 错误，附加 e.BadRequest 错误详情，参数 replace 见 mustBind()
+func failValidation(c *gin.Context, errs validator.ValidationErrors, replace map[string]string) {
 	validationError := ginvalidator.Translate(&errs)
 	if replace != nil {
 		if err := validationError.Replace(replace); err != nil {
 			fail(c, err, e.CodeInternal)
-			return false
+			return
 		}
 	}
 	var fieldViolations []*e.BadRequestFieldViolation
@@ -118,5 +278,4 @@ func mustBind(c *gin.Context, obj interface{}, replaces ...map[string]string) (s
 	}
 	badRequest := &e.BadRequest{FieldViolations: fieldViolations}
 	fail(c, validationError, e.CodeInvalidArgument, badRequest)
-	return false
 }
@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"os"
+	"project/app/handler/pkg/ginvalidator"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	if err := ginvalidator.Init(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"net"
+	"net/http"
+	"project/app/handler/pkg/e"
+	"project/app/pkg/config"
+	"strings"
+)
+
+// AdminConfig 为管理接口配置，对应配置文件中的 `admin` 节点
+type AdminConfig struct {
+	// 管理令牌，为空时禁用所有管理接口（独立管理监听地址为本机地址时除外）
+	Token string `mapstructure:"token"`
+}
+
+// NewAdminConfig 从 viper 中读取管理接口配置
+func NewAdminConfig(v *viper.Viper) (*AdminConfig, error) {
+	cfg := &AdminConfig{}
+	if err := config.Unmarshal(v, "admin", cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// AdminAuthMiddleware 用于保护管理接口（/admin/*），仅允许携带正确管理令牌的请求通过。
+//
+// 令牌通过 `Authorization: Bearer <token>` 携带，对应配置项 `admin.token`。
+// 未配置令牌时，所有管理接口均不可用；独立管理监听地址（adminAddr）例外，见 CreateLoopbackGinHandler。
+type AdminAuthMiddleware struct {
+	token string
+}
+
+func NewAdminAuthMiddleware(cfg *AdminConfig) *AdminAuthMiddleware {
+	return &AdminAuthMiddleware{token: cfg.Token}
+}
+
+// CreateGinHandler 生成 gin.HandlerFunc 实例 - 即：gin middleware 函数
+func (mw *AdminAuthMiddleware) CreateGinHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mw.handle(c, false)
+	}
+}
+
+// CreateLoopbackGinHandler 生成用于独立管理监听地址（adminAddr）的 gin middleware 函数：
+// 配置了令牌时同 CreateGinHandler；未配置令牌时，仅允许经由 loopback 地址、unix socket 建立的连接访问。
+func (mw *AdminAuthMiddleware) CreateLoopbackGinHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mw.handle(c, true)
+	}
+}
+
+func (mw *AdminAuthMiddleware) handle(c *gin.Context, allowLoopback bool) {
+	if mw.token == "" {
+		if allowLoopback && isLoopbackConn(c) {
+			c.Next()
+			return
+		}
+		fail(c, errors.New("admin token not configured"), e.CodePermissionDenied)
+		c.Abort()
+		return
+	}
+	token, ok := bearerToken(c.GetHeader("Authorization"))
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(mw.token)) != 1 {
+		fail(c, errors.New("invalid admin token"), e.CodeUnauthenticated)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// bearerToken 解析 `Authorization: Bearer <token>` 中的令牌，认证方案不区分大小写，非 Bearer 方案返回 false
+func bearerToken(header string) (string, bool) {
+	const scheme = "Bearer "
+	if len(header) <= len(scheme) || !strings.EqualFold(header[:len(scheme)], scheme) {
+		return "", false
+	}
+	return header[len(scheme):], true
+}
+
+// isLoopbackConn 判断请求所属连接的本地地址是否为 loopback 地址或 unix socket
+func isLoopbackConn(c *gin.Context) bool {
+	switch addr := c.Request.Context().Value(http.LocalAddrContextKey).(type) {
+	case *net.UnixAddr:
+		return true
+	case *net.TCPAddr:
+		return addr.IP.IsLoopback()
+	default:
+		return false
+	}
+}
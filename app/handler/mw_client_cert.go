@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"project/app/pkg/principal"
+)
+
+// ClientCertMiddleware 将 mTLS 校验通过的客户端证书转换为调用方身份（*principal.Principal）。
+//
+// 调用方身份会被存入 gin.Context 与 c.Request.Context()，handler 中通过 clientPrincipal() 获取，
+// service 层通过 principal.FromContext() 获取。未使用 TLS 或客户端未提供证书时不做任何处理。
+type ClientCertMiddleware struct {
+}
+
+// CreateGinHandler 生成 gin.HandlerFunc 实例 - 即：gin middleware 函数
+func (mw *ClientCertMiddleware) CreateGinHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 仅信任已通过 CA 校验的证书链，未校验的 PeerCertificates 不可作为身份依据
+		if state := c.Request.TLS; state != nil && len(state.VerifiedChains) > 0 && len(state.VerifiedChains[0]) > 0 {
+			p := principal.FromCertificate(state.VerifiedChains[0][0])
+			c.Set(contextKeyPrincipal, p)
+			c.Request = c.Request.WithContext(principal.NewContext(c.Request.Context(), p))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"project/app/pkg/principal"
+	"project/app/test/helper"
+	"testing"
+)
+
+func TestClientCertMiddleware(t *testing.T) {
+	a := assert.New(t)
+	ca := helper.NewCert(t, "ca", nil)
+	client := helper.NewCert(t, "billing", ca)
+
+	var got *principal.Principal
+	engine := gin.New()
+	engine.Use((&ClientCertMiddleware{}).CreateGinHandler())
+	engine.GET("/", func(c *gin.Context) {
+		got = clientPrincipal(c)
+		a.Equal(got, principal.FromContext(c.Request.Context()))
+		success(c, nil)
+	})
+	serve := func(state *tls.ConnectionState) *principal.Principal {
+		got = nil
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.TLS = state
+		engine.ServeHTTP(httptest.NewRecorder(), req)
+		return got
+	}
+
+	// 明文 http
+	a.Nil(serve(nil))
+
+	// 未经校验的客户端证书不作为身份依据
+	a.Nil(serve(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{client.Cert}}))
+
+	p := serve(&tls.ConnectionState{
+		PeerCertificates: []*x509.Certificate{client.Cert},
+		VerifiedChains:   [][]*x509.Certificate{{client.Cert, ca.Cert}},
+	})
+	if a.NotNil(p) {
+		a.Equal("billing", p.CommonName)
+		a.Equal("CN=billing,O=test", p.Subject)
+		a.Equal("CN=ca,O=test", p.Issuer)
+		a.Equal([]string{"test"}, p.Organization)
+		a.Equal([]string{"localhost"}, p.DNSNames)
+		a.Len(p.Fingerprint, 64)
+	}
+}
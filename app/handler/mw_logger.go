@@ -1,43 +1,31 @@
 package handler
 
 import (
-	"fmt"
 	"github.com/gin-gonic/gin"
-	"github.com/pkg/errors"
-	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"project/app/pkg/config"
+	"net/http"
+	"project/app/pkg/redact"
+	"project/app/pkg/tracing"
 	"time"
 )
 
 // LoggerMiddleware 用于记录 http 日志，包含请求信息、响应信息、以及错误。
+//
+// 记录日志前，使用 redactor 对错误信息、query string、http header、请求体/响应体中的敏感信息进行脱敏。
+// 请求体/响应体默认不采集，见 BodyCaptureConfig。
 type LoggerMiddleware struct {
-	zapLogger *zap.Logger
+	zapLogger  *zap.Logger
+	redactor   *redact.Redactor
+	captureCfg *BodyCaptureConfig
 }
 
-//func NewLoggerMiddleware(isDebug config.IsDebug) (mw *LoggerMiddleware, cleanup func(), err error) {
-//	var zapLogger *zap.Logger
-//
-//	if isDebug {
-//		zapLogger, err = zap.NewDevelopment()
-//	} else {
-//		zapLogger, err = zap.NewProduction()
-//	}
-//
-//	if err != nil {
-//		cleanup = func() {
-//			if err := zapLogger.Sync(); err != nil {
-//				fmt.Printf("http logger middleware zap sync() error: %s", err)
-//			}
-//		}
-//	}
-//
-//	return &LoggerMiddleware{zapLogger: zapLogger}, cleanup, err
-//}
-
-func NewLoggerMiddleware(zapLogger *zap.Logger) *LoggerMiddleware {
-	return &LoggerMiddleware{zapLogger: zapLogger}
+func NewLoggerMiddleware(
+	zapLogger *zap.Logger,
+	redactor *redact.Redactor,
+	captureCfg *BodyCaptureConfig,
+) *LoggerMiddleware {
+	return &LoggerMiddleware{zapLogger: zapLogger.Named("http"), redactor: redactor, captureCfg: captureCfg}
 }
 
 // CreateGinHandler 生成 gin.HandlerFunc 实例 - 即：gin middleware 函数
@@ -45,82 +33,144 @@ func (mw *LoggerMiddleware) CreateGinHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		startTime := time.Now()
 
+		// 按需采集请求体/响应体
+		var (
+			requestBody   *capturedBody
+			captureWriter *bodyCaptureWriter
+			captureError  error
+		)
+		if mw.captureCfg.shouldCapture(c) {
+			if mw.captureCfg.allowContentType(c.ContentType()) {
+				requestBody, captureError = captureRequestBody(c, mw.captureCfg.MaxBodySize)
+			}
+			captureWriter = &bodyCaptureWriter{ResponseWriter: c.Writer, limit: mw.captureCfg.MaxBodySize}
+			c.Writer = captureWriter
+		}
+
 		c.Next()
 
+		endTime := time.Now()
+
+		zapFields := []zap.Field{
+			zap.String("request_id", requestId(c)),
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.String("route", c.FullPath()),
+			zap.String("query", mw.redactor.Query(c.Request.URL.RawQuery)),
+			zap.Int("http_status", c.Writer.Status()),
+			zap.String("client_ip", c.ClientIP()),
+			// 请求体长度未知（如：chunked）、未写入响应体（如：中止的请求）时为 -1，记为 0
+			zap.Int64("request_size", nonNegative(c.Request.ContentLength)),
+			zap.Int64("response_size", nonNegative(int64(c.Writer.Size()))),
+			zap.Time("start_time", startTime),
+			zap.Time("end_time", endTime),
+			zap.Duration("latency", endTime.Sub(startTime)),
+			zap.String("user_agent", c.Request.UserAgent()),
+			zap.String("referer", c.Request.Referer()),
+		}
+		if headers := mw.redactor.Headers(c.Request.Header); len(headers) > 0 {
+			zapFields = append(zapFields, zap.Any("headers", headers))
+		}
+		if traceId := tracing.TraceId(c.Request.Context()); traceId != "" {
+			zapFields = append(zapFields, zap.String("trace_id", traceId))
+		}
+		if p := clientPrincipal(c); p != nil {
+			zapFields = append(zapFields, zap.String("client_subject", p.Subject))
+		}
+
 		// 获取 gin.Context 中附加的三个数据：
 		// - response body
 		// - api error：api 请求过程中发生的错误
 		// - log level：zap 记录日志时使用的错误级别
-		iBody, exists := c.Get(contextKeyBody)
-		if !exists {
-			fmt.Printf("http logger context key `%s` not exists\n", contextKeyBody)
-			return
+		//
+		// 未经过 success()、fail() 的请求（如：404 路由、被中间件中止的请求）不存在上述数据，
+		// 此时根据 http status 推断日志级别，并以 http status 文本作为日志消息。
+		var (
+			msg      string
+			logLevel zapcore.Level
+		)
+		iBody, _ := c.Get(contextKeyBody)
+		if body, ok := iBody.(*body); ok {
+			msg = body.Status
+			iLogLevel, _ := c.Get(contextKeyLogLevel)
+			if logLevel, ok = iLogLevel.(zapcore.Level); !ok {
+				logLevel = httpStatusLogLevel(c.Writer.Status())
+			}
+			zapFields = append(
+				zapFields,
+				zap.Uint8("code", uint8(body.Code)),
+				zap.String("code_name", body.Status),
+				zap.String("msg", body.Message),
+			)
+			if apiError, ok := c.Value(contextKeyError).(error); ok && apiError != nil {
+				zapFields = append(zapFields, zap.String("error", mw.redactor.Error(apiError, false)))
+				if verbose := mw.redactor.Error(apiError, true); verbose != apiError.Error() {
+					zapFields = append(zapFields, zap.String("error_verbose", verbose))
+				}
+			}
+		} else {
+			msg = http.StatusText(c.Writer.Status())
+			logLevel = httpStatusLogLevel(c.Writer.Status())
 		}
-		body, ok := iBody.(*body)
-		if !ok {
-			fmt.Printf("http logger context key `%s` type invalid\n", contextKeyBody)
-			return
+		if requestBody != nil && len(requestBody.data) > 0 {
+			zapFields = append(zapFields, mw.bodyFields("request", c.ContentType(), requestBody)...)
 		}
-		iLogLevel, exists := c.Get(contextKeyLogLevel)
-		if !exists {
-			fmt.Printf("http logger context key `%s` not exists\n", contextKeyLogLevel)
-			return
+		if captureWriter != nil && mw.captureCfg.allowContentType(captureWriter.Header().Get("Content-Type")) {
+			zapFields = append(zapFields,
+				mw.bodyFields("response", captureWriter.Header().Get("Content-Type"), &captureWriter.body)...)
 		}
-		logLevel, ok := iLogLevel.(zapcore.Level)
-		if !ok {
-			fmt.Printf("http logger context key `%s` type invalid\n", contextKeyLogLevel)
-			return
+		if captureError != nil {
+			zapFields = append(zapFields, zap.String("capture_error", captureError.Error()))
+		}
+		if len(c.Errors) > 0 {
+			zapFields = append(zapFields, zap.Strings("gin_errors", c.Errors.Errors()))
 		}
-		apiError, _ := c.Get(contextKeyError)
 
-		endtime := time.Now()
+		mw.log(logLevel, msg, zapFields...)
+	}
+}
 
-		var zapFields []zap.Field
-		zapFields = append(
-			zapFields,
-			zap.String("method", c.Request.Method),
-			zap.String("path", c.Request.URL.Path),
-			zap.Int("http_status", c.Writer.Status()),
-			zap.Uint8("code", uint8(body.Code)),
-			zap.String("code_name", body.Status),
-			zap.String("msg", body.Message),
-			zap.Any("error", apiError),
-			zap.Time("start_time", startTime),
-			zap.Time("end_time", endtime),
-			zap.String("user_agent", c.Request.UserAgent()),
-			zap.String("referer", c.Request.Referer()),
-		)
-		switch logLevel {
-		case zapcore.DebugLevel:
-			mw.zapLogger.Debug(body.Status, zapFields...)
-		case zapcore.InfoLevel:
-			mw.zapLogger.Debug(body.Status, zapFields...)
-		case zapcore.WarnLevel:
-			mw.zapLogger.Debug(body.Status, zapFields...)
-		case zapcore.FatalLevel:
-			mw.zapLogger.Debug(body.Status, zapFields...)
-		default:
-			fmt.Printf("unsupported log level: %s\n", logLevel)
-		}
+// bodyFields 生成请求体/响应体的日志字段，prefix 为 request 或 response
+func (mw *LoggerMiddleware) bodyFields(prefix, contentType string, body *capturedBody) []zap.Field {
+	fields := []zap.Field{zap.String(prefix+"_body", mw.redactor.Body(contentType, body.data))}
+	if body.truncated {
+		fields = append(fields, zap.Bool(prefix+"_body_truncated", true))
 	}
+	return fields
 }
 
-// 实例化一个 *zap.Logger，该实例用于注入 LoggerMiddleware
-func NewZapLogger(isDebug config.IsDebug, v *viper.Viper) (zapLogger *zap.Logger, cleanup func(), err error) {
-	// @todo 以下为 mock 代码
-	if isDebug {
-		if zapLogger, err = zap.NewDevelopment(); err != nil {
-			return nil, nil, errors.Wrap(err, "new development zapLogger failed")
-		}
-	} else {
-		if zapLogger, err = zap.NewDevelopment(); err != nil {
-			return nil, nil, errors.Wrap(err, "new production zapLogger failed")
-		}
+// log 按日志级别分发日志
+//
+// 注意：DPanic、Panic、Fatal 级别均按 Error 级别记录，避免一次请求的日志导致进程 panic 或退出。
+func (mw *LoggerMiddleware) log(logLevel zapcore.Level, msg string, fields ...zap.Field) {
+	switch logLevel {
+	case zapcore.DebugLevel:
+		mw.zapLogger.Debug(msg, fields...)
+	case zapcore.InfoLevel:
+		mw.zapLogger.Info(msg, fields...)
+	case zapcore.WarnLevel:
+		mw.zapLogger.Warn(msg, fields...)
+	default:
+		mw.zapLogger.Error(msg, fields...)
 	}
-	cleanup = func() {
-		if err := zapLogger.Sync(); err != nil {
-			fmt.Printf("zap sync() error: %s", err)
-		}
+}
+
+// httpStatusLogLevel 根据 http status 推断日志级别：5xx -> Error，4xx -> Warn，其他 -> Info
+func httpStatusLogLevel(httpStatus int) zapcore.Level {
+	switch {
+	case httpStatus >= http.StatusInternalServerError:
+		return zapcore.ErrorLevel
+	case httpStatus >= http.StatusBadRequest:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
+
+// nonNegative 将负数（表示长度未知）转换为 0
+func nonNegative(n int64) int64 {
+	if n < 0 {
+		return 0
 	}
-	return zapLogger, cleanup, nil
+	return n
 }
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"project/app/pkg/config"
+	"strings"
+)
+
+// BodyCaptureConfig 为 http 日志的请求体/响应体采集配置，对应配置文件中的 `log.capture` 节点
+type BodyCaptureConfig struct {
+	// 是否默认采集，可被 Routes 中的规则覆盖
+	Enabled bool `mapstructure:"enabled"`
+	// 请求体/响应体的最大采集字节数，超出部分将被截断
+	MaxBodySize int `mapstructure:"maxBodySize" validate:"gt=0"`
+	// 允许采集的 Content-Type（前缀匹配）
+	ContentTypes []string `mapstructure:"contentTypes"`
+	// 采样率，取值 0-1
+	SampleRate float64 `mapstructure:"sampleRate" validate:"gte=0,lte=1"`
+	// 按路由开启/关闭采集
+	Routes []BodyCaptureRoute `mapstructure:"routes" validate:"dive"`
+}
+
+// BodyCaptureRoute 为单个路由的采集开关
+type BodyCaptureRoute struct {
+	// http method，为空时匹配所有 method
+	Method string `mapstructure:"method"`
+	// 路由模板，即 gin.Context.FullPath()，例：/sms/login
+	Path string `mapstructure:"path" validate:"required"`
+	// 是否采集
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// NewBodyCaptureConfig 从 viper 中读取请求体/响应体采集配置
+func NewBodyCaptureConfig(v *viper.Viper) (*BodyCaptureConfig, error) {
+	cfg := &BodyCaptureConfig{
+		MaxBodySize: 4096,
+		SampleRate:  1,
+	}
+	if err := config.Unmarshal(v, "log.capture", cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// shouldCapture 判断当前请求是否需要采集请求体/响应体
+func (cfg *BodyCaptureConfig) shouldCapture(c *gin.Context) bool {
+	enabled := cfg.Enabled
+	for _, route := range cfg.Routes {
+		if route.Path == c.FullPath() &&
+			(route.Method == "" || strings.EqualFold(route.Method, c.Request.Method)) {
+			enabled = route.Enabled
+			break
+		}
+	}
+	if !enabled || cfg.SampleRate <= 0 {
+		return false
+	}
+	return cfg.SampleRate >= 1 || rand.Float64() < cfg.SampleRate
+}
+
+// allowContentType 判断 Content-Type 是否允许采集
+func (cfg *BodyCaptureConfig) allowContentType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, allowed := range cfg.ContentTypes {
+		if strings.HasPrefix(contentType, strings.ToLower(allowed)) {
+			return true
+		}
+	}
+	return false
+}
+
+// capturedBody 为采集到的请求体/响应体
+type capturedBody struct {
+	data      []byte
+	truncated bool // 是否超出最大采集字节数而被截断
+}
+
+// captureRequestBody 读取最多 limit 字节的请求体，并将已读取的部分还原到 c.Request.Body，不影响后续绑定。
+func captureRequestBody(c *gin.Context, limit int) (*capturedBody, error) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return &capturedBody{}, nil
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(c.Request.Body, int64(limit)+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "read request body failed")
+	}
+	c.Request.Body = &multiReadCloser{
+		Reader: io.MultiReader(bytes.NewReader(data), c.Request.Body),
+		Closer: c.Request.Body,
+	}
+	if len(data) > limit {
+		return &capturedBody{data: data[:limit], truncated: true}, nil
+	}
+	return &capturedBody{data: data}, nil
+}
+
+type multiReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyCaptureWriter 包装 gin.ResponseWriter，在写出响应体的同时采集最多 limit 字节
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	limit int
+	body  capturedBody
+}
+
+func (w *bodyCaptureWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyCaptureWriter) capture(data []byte) {
+	remain := w.limit - len(w.body.data)
+	if len(data) > remain {
+		data = data[:remain]
+		w.body.truncated = true
+	}
+	w.body.data = append(w.body.data, data...)
+}
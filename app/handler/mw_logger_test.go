@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"net/http"
+	"net/http/httptest"
+	"project/app/handler/pkg/e"
+	"project/app/pkg/redact"
+	"project/app/test/helper"
+	"strings"
+	"testing"
+)
+
+func newObservedLoggerEngine(t *testing.T, captureCfg ...*BodyCaptureConfig) (*gin.Engine, *observer.ObservedLogs) {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	engine := gin.New()
+	redactor, err := redact.New(redact.DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &BodyCaptureConfig{}
+	if len(captureCfg) > 0 {
+		cfg = captureCfg[0]
+	}
+	engine.Use(
+		(&RequestIdMiddleware{}).CreateGinHandler(),
+		NewLoggerMiddleware(zap.New(core), redactor, cfg).CreateGinHandler(),
+	)
+	return engine, logs
+}
+
+func TestLoggerMiddleware_LogLevel(t *testing.T) {
+	a := assert.New(t)
+	engine, logs := newObservedLoggerEngine(t)
+	engine.GET("/ok", func(c *gin.Context) { success(c, nil) })
+	engine.GET("/invalid", func(c *gin.Context) { fail(c, errors.New("invalid"), e.CodeInvalidArgument) })
+	engine.GET("/internal", func(c *gin.Context) { fail(c, errors.New("internal"), e.CodeInternal) })
+
+	expect := helper.NewHttpExcept(t, engine)
+	expect.GET("/ok").Expect().Status(http.StatusOK)
+	expect.GET("/invalid").Expect().Status(http.StatusBadRequest)
+	expect.GET("/internal").Expect().Status(http.StatusInternalServerError)
+
+	entries := logs.AllUntimed()
+	a.Len(entries, 3)
+	a.Equal(zapcore.InfoLevel, entries[0].Level)
+	a.Equal("OK", entries[0].Message)
+	a.Equal("http", entries[0].LoggerName)
+	a.Equal(zapcore.WarnLevel, entries[1].Level)
+	a.Equal("INVALID_ARGUMENT", entries[1].Message)
+	a.Equal(zapcore.ErrorLevel, entries[2].Level)
+	a.Equal("INTERNAL", entries[2].Message)
+	a.Equal("/internal", entries[2].ContextMap()["route"])
+}
+
+func TestLoggerMiddleware_UnknownSize(t *testing.T) {
+	a := assert.New(t)
+	engine, logs := newObservedLoggerEngine(t)
+	engine.POST("/empty", func(c *gin.Context) {})
+
+	// 请求体长度未知、未写入响应体
+	req := httptest.NewRequest(http.MethodPost, "/empty", strings.NewReader("chunked body"))
+	req.ContentLength = -1
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logs.AllUntimed()
+	a.Len(entries, 1)
+	a.Equal(int64(0), entries[0].ContextMap()["request_size"])
+	a.Equal(int64(0), entries[0].ContextMap()["response_size"])
+}
+
+func TestLoggerMiddleware_WithoutBody(t *testing.T) {
+	a := assert.New(t)
+	engine, logs := newObservedLoggerEngine(t)
+	engine.GET("/abort", func(c *gin.Context) { c.AbortWithStatus(http.StatusServiceUnavailable) })
+
+	expect := helper.NewHttpExcept(t, engine)
+	expect.GET("/not-found").WithHeader(headerRequestId, "req-1").Expect().Status(http.StatusNotFound)
+	expect.GET("/abort").Expect().Status(http.StatusServiceUnavailable)
+
+	entries := logs.AllUntimed()
+	a.Len(entries, 2)
+	a.Equal(zapcore.WarnLevel, entries[0].Level)
+	a.Equal(http.StatusText(http.StatusNotFound), entries[0].Message)
+	a.Equal("req-1", entries[0].ContextMap()["request_id"])
+	a.Equal("", entries[0].ContextMap()["route"])
+	a.Equal(zapcore.ErrorLevel, entries[1].Level)
+	a.NotContains(entries[1].ContextMap(), "code")
+}
+
+func TestLoggerMiddleware_Redact(t *testing.T) {
+	a := assert.New(t)
+	engine, logs := newObservedLoggerEngine(t)
+	engine.GET("/sms", func(c *gin.Context) {
+		fail(c, errors.Errorf("send sms to %s failed", c.Query("phone")), e.CodeInternal)
+	})
+
+	helper.NewHttpExcept(t, engine).GET("/sms").
+		WithQuery("phone", "13812341234").
+		WithQuery("verify_code", "123456").
+		WithHeader("Authorization", "Bearer token").
+		WithHeader("X-Request-Id", "req-1").
+		Expect().Status(http.StatusInternalServerError)
+
+	fields := logs.All()[0].ContextMap()
+	a.Equal("send sms to 138****1234 failed", fields["error"])
+	a.Contains(fields["error_verbose"], "send sms to 138****1234 failed")
+	a.NotContains(fields["error_verbose"], "13812341234")
+	a.Equal("phone=138%2A%2A%2A%2A1234&verify_code=%2A%2A%2A", fields["query"])
+	a.Equal(map[string]string{"X-Request-Id": "req-1"}, fields["headers"])
+}
+
+func TestLoggerMiddleware_BodyCapture(t *testing.T) {
+	a := assert.New(t)
+	engine, logs := newObservedLoggerEngine(t, &BodyCaptureConfig{
+		MaxBodySize:  128,
+		ContentTypes: []string{"application/json"},
+		SampleRate:   1,
+		Routes: []BodyCaptureRoute{
+			{Method: "POST", Path: "/sms/login", Enabled: true},
+		},
+	})
+	engine.POST("/sms/login", func(c *gin.Context) {
+		var form struct {
+			CnCellPhoneNumber string `json:"cn_cell_phone_number"`
+		}
+		if mustBind(c, &form) {
+			success(c, form)
+		}
+	})
+	engine.POST("/other", func(c *gin.Context) { success(c, nil) })
+
+	expect := helper.NewHttpExcept(t, engine)
+	expect.POST("/sms/login").WithJSON(map[string]string{"cn_cell_phone_number": "13812341234"}).
+		Expect().Status(http.StatusOK).
+		JSON().Object().Path("$.data.cn_cell_phone_number").Equal("13812341234")
+	expect.POST("/sms/login").WithText(strings.Repeat("a", 200)).
+		Expect().Status(http.StatusOK)
+	expect.POST("/sms/login").WithJSON(map[string]string{"cn_cell_phone_number": strings.Repeat("1", 200)}).
+		Expect().Status(http.StatusOK)
+	expect.POST("/other").WithJSON(map[string]string{"cn_cell_phone_number": "13812341234"}).
+		Expect().Status(http.StatusOK)
+
+	entries := logs.AllUntimed()
+	a.Len(entries, 4)
+
+	fields := entries[0].ContextMap()
+	a.Equal(`{"cn_cell_phone_number":"138****1234"}`, fields["request_body"])
+	a.Contains(fields["response_body"], `"cn_cell_phone_number":"138****1234"`)
+	a.NotContains(fields, "response_body_truncated")
+
+	// 请求体 Content-Type 不在允许列表中
+	a.NotContains(entries[1].ContextMap(), "request_body")
+	a.Contains(entries[1].ContextMap(), "response_body")
+
+	// 超出最大采集字节数
+	a.Equal(true, entries[2].ContextMap()["request_body_truncated"])
+	a.Equal(true, entries[2].ContextMap()["response_body_truncated"])
+
+	// 未开启采集的路由
+	a.NotContains(entries[3].ContextMap(), "request_body")
+	a.NotContains(entries[3].ContextMap(), "response_body")
+}
+
+func TestLoggerMiddleware_BodyCaptureTruncatedRedact(t *testing.T) {
+	a := assert.New(t)
+	engine, logs := newObservedLoggerEngine(t, &BodyCaptureConfig{
+		MaxBodySize:  48,
+		ContentTypes: []string{"application/json"},
+		SampleRate:   1,
+		Routes: []BodyCaptureRoute{
+			{Method: "POST", Path: "/sms/login", Enabled: true},
+		},
+	})
+	engine.POST("/sms/login", func(c *gin.Context) { success(c, nil) })
+
+	// 截断后的请求体不是合法的 JSON，敏感字段的值仍须掩码
+	helper.NewHttpExcept(t, engine).POST("/sms/login").
+		WithHeader("Content-Type", "application/json").
+		WithText(`{"sms_code":"654321","cn_cell_phone_number":"13812341234","padding":"` + strings.Repeat("x", 100) + `"}`).
+		Expect().Status(http.StatusOK)
+
+	fields := logs.All()[0].ContextMap()
+	a.Equal(true, fields["request_body_truncated"])
+	a.Contains(fields["request_body"], `"sms_code":"***"`)
+	a.NotContains(fields["request_body"], "654321")
+	a.NotContains(fields["request_body"], "13812341234")
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"project/app/pkg/metrics"
+	"time"
+)
+
+// routeUnmatched 为未匹配任何路由的请求（如：404）的 route 标签值，避免以原始 path 作为标签导致指标基数膨胀
+const routeUnmatched = "unmatched"
+
+// MetricsMiddleware 用于记录 http 请求指标：请求数、请求耗时。
+//
+// 指标标签为路由模板（例：/admin/panics/:fingerprint）、http method、http status、业务错误码名称，
+// 未经过 success()、fail() 的请求，业务错误码名称为空。
+type MetricsMiddleware struct {
+	httpMetrics *metrics.HttpMetrics
+}
+
+func NewMetricsMiddleware(httpMetrics *metrics.HttpMetrics) *MetricsMiddleware {
+	return &MetricsMiddleware{httpMetrics: httpMetrics}
+}
+
+// CreateGinHandler 生成 gin.HandlerFunc 实例 - 即：gin middleware 函数
+func (mw *MetricsMiddleware) CreateGinHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		startTime := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = routeUnmatched
+		}
+		var code string
+		if body, ok := c.Value(contextKeyBody).(*body); ok {
+			code = body.Status
+		}
+		mw.httpMetrics.Observe(route, c.Request.Method, c.Writer.Status(), code, time.Since(startTime))
+	}
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"project/app/handler/pkg/e"
+	"project/app/pkg/metrics"
+	"project/app/test/helper"
+	"testing"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	a := assert.New(t)
+	registry := prometheus.NewRegistry()
+	httpMetrics, err := metrics.NewHttpMetrics(&metrics.Config{Buckets: prometheus.DefBuckets}, registry)
+	a.Nil(err)
+
+	engine := gin.New()
+	engine.Use(NewMetricsMiddleware(httpMetrics).CreateGinHandler())
+	engine.GET("/users/:id", func(c *gin.Context) {
+		if c.Param("id") == "0" {
+			fail(c, errors.New("user not found"), e.CodeNotFound)
+			return
+		}
+		success(c, nil)
+	})
+	engine.GET("/metrics", NewMetricsCtrl(registry).Get)
+	expect := helper.NewHttpExcept(t, engine)
+
+	expect.GET("/users/1").Expect().Status(http.StatusOK)
+	expect.GET("/users/2").Expect().Status(http.StatusOK)
+	expect.GET("/users/0").Expect().Status(http.StatusNotFound)
+	expect.GET("/not-exists").Expect().Status(http.StatusNotFound)
+
+	// 以路由模板、业务错误码名称作为标签；未匹配路由的请求统一记为 unmatched
+	body := expect.GET("/metrics").Expect().Status(http.StatusOK).Body()
+	body.Contains(`http_requests_total{code="OK",method="GET",route="/users/:id",status="200"} 2`)
+	body.Contains(`http_requests_total{code="NOT_FOUND",method="GET",route="/users/:id",status="404"} 1`)
+	body.Contains(`http_requests_total{code="",method="GET",route="unmatched",status="404"} 1`)
+	body.NotContains("/not-exists")
+
+	// 包含 /metrics 请求本身
+	count, err := testutil.GatherAndCount(registry, "http_request_duration_seconds")
+	a.Nil(err)
+	a.Equal(4, count)
+}
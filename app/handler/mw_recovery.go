@@ -1,46 +1,147 @@
 package handler
 
 import (
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 	"net"
 	"net/http"
 	"os"
 	"project/app/handler/pkg/e"
+	"project/app/pkg/config"
+	"project/app/pkg/logger"
+	"project/app/pkg/panicreport"
+	"project/app/pkg/redact"
+	"runtime"
 	"strings"
 )
 
+// RecoveryMiddleware 捕获请求处理过程中的 panic，记录错误日志及堆栈，并响应 e.CodeInternal 错误。
+//
+// 开发者模式下，响应中会附加包含堆栈信息的 e.DebugInfo 错误详情。
+// 客户端连接已断开（broken pipe、connection reset）导致的 panic 不记录、不响应。
+// 其他 panic 均交由 panicreport.Recorder 按调用栈指纹汇总。日志中的 panic 信息、请求路径经 redactor 脱敏。
 type RecoveryMiddleware struct {
+	isDebug   config.IsDebug
+	zapLogger *zap.Logger
+	recorder  *panicreport.Recorder
+	redactor  *redact.Redactor
 }
 
+func NewRecoveryMiddleware(
+	isDebug config.IsDebug,
+	zapLogger *zap.Logger,
+	recorder *panicreport.Recorder,
+	redactor *redact.Redactor,
+) *RecoveryMiddleware {
+	return &RecoveryMiddleware{isDebug: isDebug, zapLogger: zapLogger.Named("recovery"), recorder: recorder, redactor: redactor}
+}
+
+// CreateGinHandler 生成 gin.HandlerFunc 实例 - 即：gin middleware 函数
 func (mw *RecoveryMiddleware) CreateGinHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
-			if err := recover(); err != nil {
-				// Check for a broken connection, as it is not really a
-				// condition that warrants a panic stack trace.
-				var brokenPipe bool
-				if ne, ok := err.(*net.OpError); ok {
-					if se, ok := ne.Err.(*os.SyscallError); ok {
-						if strings.Contains(strings.ToLower(se.Error()), "broken pipe") || strings.Contains(strings.ToLower(se.Error()), "connection reset by peer") {
-							brokenPipe = true
-						}
-					}
-				}
-
-				// If the connection is dead, we can't write a status to it.
-				if brokenPipe {
-					switch err.(type) {
-					case string:
-						fail(c, errors.New("application panic: "+err.(string)), e.CodeInternal)
-					case error:
-						fail(c, errors.Wrap(err.(error), "application panic"), e.CodeInternal)
-					}
-				} else {
-					c.AbortWithStatus(http.StatusInternalServerError)
-				}
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			// Check for a broken connection, as it is not really a
+			// condition that warrants a panic stack trace.
+			// If the connection is dead, we can't write a status to it.
+			if isBrokenPipe(recovered) {
+				c.Abort()
+				return
+			}
+
+			err := panicError(recovered)
+			frames := panicFrames()
+			entries := panicreport.FormatFrames(frames)
+			report := mw.recorder.Record(err, frames, panicreport.Sample{
+				RequestId: requestId(c),
+				Method:    c.Request.Method,
+				Path:      c.Request.URL.Path,
+				Route:     c.FullPath(),
+				ClientIP:  c.ClientIP(),
+				UserAgent: c.Request.UserAgent(),
+			})
+			logger.WithContext(c.Request.Context(), mw.zapLogger).Error(
+				"panic recovered",
+				zap.String("error", mw.redactor.Error(err, false)),
+				zap.String("panic_fingerprint", report.Fingerprint),
+				zap.Int64("panic_count", report.Count),
+				zap.String("method", c.Request.Method),
+				zap.String("path", mw.redactor.String(c.Request.URL.Path)),
+				zap.Strings("stack", entries),
+			)
+
+			// 响应已开始写出时，无法再写出错误响应
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			var errorDetails []e.IErrorDetail
+			if mw.isDebug {
+				errorDetails = append(errorDetails, &e.DebugInfo{
+					StackEntries: entries,
+					Detail:       err.Error(),
+				})
 			}
+			fail(c, err, e.CodeInternal, errorDetails...)
+			c.Abort()
 		}()
 		c.Next()
 	}
 }
+
+// isBrokenPipe 判断 panic 是否由客户端连接断开引起
+func isBrokenPipe(recovered interface{}) bool {
+	if recovered == http.ErrAbortHandler {
+		return true
+	}
+	ne, ok := recovered.(*net.OpError)
+	if !ok {
+		return false
+	}
+	se, ok := ne.Err.(*os.SyscallError)
+	if !ok {
+		return false
+	}
+	msg := strings.ToLower(se.Error())
+	return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+}
+
+// panicError 将 recover() 得到的任意值转换为 error
+func panicError(recovered interface{}) error {
+	switch v := recovered.(type) {
+	case string:
+		return errors.New("application panic: " + v)
+	case error:
+		return errors.Wrap(v, "application panic")
+	default:
+		return errors.Errorf("application panic: %s", fmt.Sprint(v))
+	}
+}
+
+// panicFrames 获取 panic 发生处的调用栈，需在 defer 函数中直接调用。
+//
+// 返回的调用栈从触发 panic 的函数开始，不包含 runtime.gopanic 及之前的 recovery 相关帧。
+func panicFrames() []runtime.Frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	callersFrames := runtime.CallersFrames(pcs[:n])
+
+	var frames []runtime.Frame
+	for {
+		frame, more := callersFrames.Next()
+		frames = append(frames, frame)
+		if frame.Function == "runtime.gopanic" {
+			frames = frames[:0]
+		}
+		if !more {
+			break
+		}
+	}
+	return frames
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"net"
+	"net/http"
+	"os"
+	"project/app/handler/pkg/e"
+	"project/app/pkg/config"
+	"project/app/pkg/panicreport"
+	"project/app/pkg/redact"
+	"project/app/test/helper"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func newRecoveryEngine(t *testing.T, isDebug bool) (*gin.Engine, *observer.ObservedLogs, *panicreport.Recorder) {
+	t.Helper()
+	core, logs := observer.New(zapcore.DebugLevel)
+	zapLogger := zap.New(core)
+	redactor, err := redact.New(redact.DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder, cleanup, err := panicreport.NewRecorder(&panicreport.Config{
+		FingerprintFrames: 5,
+		StackFrames:       32,
+		Window:            time.Minute,
+		FlushInterval:     time.Minute,
+	}, panicreport.NewLogNotifier(zapLogger), redactor, zapLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cleanup)
+
+	engine := gin.New()
+	engine.Use(
+		(&RequestIdMiddleware{}).CreateGinHandler(),
+		NewRecoveryMiddleware(config.IsDebug(isDebug), zapLogger, recorder, redactor).CreateGinHandler(),
+	)
+	engine.GET("/string", func(c *gin.Context) { panic("boom") })
+	engine.GET("/error", func(c *gin.Context) { panic(errors.New("boom")) })
+	engine.GET("/int", func(c *gin.Context) { panic(42) })
+	engine.GET("/sms/:phone", func(c *gin.Context) { panic("send sms to " + c.Param("phone") + " failed") })
+	engine.GET("/broken-pipe", func(c *gin.Context) {
+		panic(&net.OpError{Op: "write", Err: os.NewSyscallError("write", syscall.EPIPE)})
+	})
+	return engine, logs, recorder
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	a := assert.New(t)
+	engine, logs, recorder := newRecoveryEngine(t, false)
+	expect := helper.NewHttpExcept(t, engine)
+
+	for _, path := range []string{"/string", "/error", "/int"} {
+		body := expect.GET(path).Expect().Status(http.StatusInternalServerError).JSON().Object()
+		body.ValueEqual("code", e.CodeInternal)
+		// 非开发者模式下仅附加 e.RequestInfo
+		body.Value("error").Array().Length().Equal(1)
+	}
+
+	entries := logs.AllUntimed()
+	a.Len(entries, 3)
+	a.Equal(zapcore.ErrorLevel, entries[0].Level)
+	a.Equal("recovery", entries[0].LoggerName)
+	a.Equal("application panic: boom", entries[0].ContextMap()["error"])
+	a.Equal("application panic: 42", entries[2].ContextMap()["error"])
+	stack := entries[0].ContextMap()["stack"].([]interface{})
+	a.Contains(stack[0], "newRecoveryEngine")
+
+	// 同一位置的 panic 按指纹汇总
+	expect.GET("/string").Expect().Status(http.StatusInternalServerError)
+	reports := recorder.List()
+	a.Len(reports, 3)
+	a.Equal(int64(2), reports[0].Count)
+	a.Equal("/string", reports[0].Sample.Route)
+	a.Equal(reports[0].Fingerprint, logs.All()[3].ContextMap()["panic_fingerprint"])
+}
+
+func TestRecoveryMiddleware_Redact(t *testing.T) {
+	a := assert.New(t)
+	engine, logs, recorder := newRecoveryEngine(t, false)
+	helper.NewHttpExcept(t, engine).GET("/sms/13812341234").Expect().Status(http.StatusInternalServerError)
+
+	fields := logs.All()[0].ContextMap()
+	a.Equal("application panic: send sms to 138****1234 failed", fields["error"])
+	a.Equal("/sms/138****1234", fields["path"])
+	reports := recorder.List()
+	a.Len(reports, 1)
+	a.Equal("application panic: send sms to 138****1234 failed", reports[0].Message)
+	a.Equal("/sms/138****1234", reports[0].Sample.Path)
+}
+
+func TestRecoveryMiddleware_Debug(t *testing.T) {
+	engine, _, _ := newRecoveryEngine(t, true)
+	debugInfo := helper.NewHttpExcept(t, engine).GET("/string").
+		Expect().Status(http.StatusInternalServerError).
+		JSON().Object().Value("error").Array().Element(0).Object()
+	debugInfo.ValueEqual("detail", "application panic: boom")
+	debugInfo.Value("stack_entries").Array().Element(0).String().Contains("newRecoveryEngine")
+}
+
+func TestRecoveryMiddleware_BrokenPipe(t *testing.T) {
+	a := assert.New(t)
+	engine, logs, recorder := newRecoveryEngine(t, true)
+	helper.NewHttpExcept(t, engine).GET("/broken-pipe").
+		Expect().Body().Empty()
+	a.Equal(0, logs.Len())
+	a.Len(recorder.List(), 0)
+}
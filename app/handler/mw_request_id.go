@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"project/app/pkg/requestid"
+)
+
+// RequestIdMiddleware 为每个请求分配请求 ID。
+//
+// 优先使用客户端通过 `X-Request-Id` header 传入的合法请求 ID，否则生成新的请求 ID。
+// 请求 ID 会被存入 gin.Context 与 c.Request.Context()，并通过 `X-Request-Id` header 返回给客户端，
+// http 日志及 fail() 返回的 e.RequestInfo 错误详情中均包含该请求 ID。
+type RequestIdMiddleware struct {
+}
+
+// CreateGinHandler 生成 gin.HandlerFunc 实例 - 即：gin middleware 函数
+func (mw *RequestIdMiddleware) CreateGinHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(headerRequestId)
+		if !requestid.IsValid(id) {
+			id = requestid.Generate()
+		}
+		c.Set(contextKeyRequestId, id)
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), id))
+		c.Header(headerRequestId, id)
+		c.Next()
+	}
+}
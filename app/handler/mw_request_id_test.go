@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"project/app/handler/pkg/e"
+	"project/app/pkg/requestid"
+	"project/app/test/helper"
+	"testing"
+)
+
+func TestRequestIdMiddleware(t *testing.T) {
+	a := assert.New(t)
+	engine := gin.New()
+	engine.Use((&RequestIdMiddleware{}).CreateGinHandler())
+	engine.GET("/ok", func(c *gin.Context) {
+		a.Equal(requestId(c), requestid.FromContext(c.Request.Context()))
+		success(c, nil)
+	})
+	engine.GET("/fail", func(c *gin.Context) {
+		fail(c, errors.New("not found"), e.CodeNotFound, &e.ResourceInfo{ResourceName: "x"})
+	})
+
+	expect := helper.NewHttpExcept(t, engine)
+
+	// 使用客户端传入的合法请求 ID
+	expect.GET("/ok").WithHeader(headerRequestId, "req-1").
+		Expect().Status(http.StatusOK).Header(headerRequestId).Equal("req-1")
+
+	// 客户端传入的请求 ID 不合法时，重新生成
+	id := expect.GET("/ok").WithHeader(headerRequestId, "bad id").
+		Expect().Status(http.StatusOK).Header(headerRequestId).Raw()
+	a.True(requestid.IsValid(id))
+	a.NotEqual("bad id", id)
+
+	// fail() 自动附加 e.RequestInfo 错误详情
+	errs := expect.GET("/fail").WithHeader(headerRequestId, "req-2").
+		Expect().Status(http.StatusNotFound).
+		JSON().Object().Value("error").Array()
+	errs.Length().Equal(2)
+	errs.Element(1).Object().ValueEqual("request_id", "req-2")
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"project/app/pkg/redact"
+	"project/app/pkg/tracing"
+)
+
+// TracingMiddleware 为每个请求创建 server span，span 名称为路由模板（例：/sms/login），未匹配路由的请求为 `HTTP GET` 等。
+//
+// 客户端通过 W3C `traceparent` header 传入追踪上下文时，span 作为其子 span，并遵循其采样决定。
+// span 存入 c.Request.Context()，service 等下层调用可以此创建子 span；trace id 记录在 http 日志中，
+// 并由 fail() 通过 e.RequestInfo 错误详情返回给客户端。
+//
+// 5xx 响应的 span 标记为错误，错误信息经 redactor 脱敏后记录为 span 状态描述。
+type TracingMiddleware struct {
+	tracer   trace.Tracer
+	redactor *redact.Redactor
+}
+
+func NewTracingMiddleware(tracerProvider trace.TracerProvider, redactor *redact.Redactor) *TracingMiddleware {
+	return &TracingMiddleware{tracer: tracerProvider.Tracer("project/app/handler"), redactor: redactor}
+}
+
+// CreateGinHandler 生成 gin.HandlerFunc 实例 - 即：gin middleware 函数
+func (mw *TracingMiddleware) CreateGinHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := tracing.Propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		route := c.FullPath()
+		spanName := route
+		if spanName == "" {
+			spanName = "HTTP " + c.Request.Method
+		}
+		attrs := []attribute.KeyValue{
+			semconv.HTTPMethodKey.String(c.Request.Method),
+			semconv.HTTPTargetKey.String(c.Request.URL.Path),
+			semconv.HTTPClientIPKey.String(c.ClientIP()),
+			semconv.HTTPUserAgentKey.String(c.Request.UserAgent()),
+		}
+		if route != "" {
+			attrs = append(attrs, semconv.HTTPRouteKey.String(route))
+		}
+		if id := requestId(c); id != "" {
+			attrs = append(attrs, attribute.String("http.request_id", id))
+		}
+		ctx, span := mw.tracer.Start(ctx, spanName,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attrs...),
+		)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+		if body, ok := c.Value(contextKeyBody).(*body); ok {
+			span.SetAttributes(attribute.String("app.code", body.Status))
+		}
+		if status >= http.StatusInternalServerError {
+			description := http.StatusText(status)
+			if apiError, ok := c.Value(contextKeyError).(error); ok && apiError != nil {
+				description = mw.redactor.Error(apiError, false)
+			}
+			span.SetStatus(codes.Error, description)
+		}
+	}
+}
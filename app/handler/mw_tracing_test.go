@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"project/app/handler/pkg/e"
+	"project/app/pkg/redact"
+	"project/app/test/helper"
+	"testing"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	a := assert.New(t)
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	redactor, err := redact.New(redact.DefaultConfig)
+	a.Nil(err)
+
+	engine := gin.New()
+	engine.Use(
+		(&RequestIdMiddleware{}).CreateGinHandler(),
+		NewTracingMiddleware(provider, redactor).CreateGinHandler(),
+	)
+	engine.GET("/users/:id", func(c *gin.Context) {
+		fail(c, errors.New("query user 13812341234 failed"), e.CodeInternal)
+	})
+	expect := helper.NewHttpExcept(t, engine)
+
+	// 沿用客户端传入的 traceparent，trace id 通过 e.RequestInfo 返回
+	const traceId = "4bf92f3577b34da6a3ce929d0e0e4736"
+	expect.GET("/users/1").
+		WithHeader("traceparent", "00-"+traceId+"-00f067aa0ba902b7-01").
+		Expect().Status(http.StatusInternalServerError).
+		JSON().Object().Value("error").Array().Element(0).Object().
+		ValueEqual("serving_data", traceId)
+
+	spans := recorder.Ended()
+	a.Len(spans, 1)
+	span := spans[0]
+	a.Equal("/users/:id", span.Name())
+	a.Equal(traceId, span.SpanContext().TraceID().String())
+	a.Equal("00f067aa0ba902b7", span.Parent().SpanID().String())
+	a.True(span.Parent().IsRemote())
+	a.Equal(codes.Error, span.Status().Code)
+	// 错误信息经过脱敏
+	a.Equal("query user 138****1234 failed", span.Status().Description)
+
+	// 未匹配路由
+	expect.GET("/not-exists").Expect().Status(http.StatusNotFound)
+	spans = recorder.Ended()
+	a.Len(spans, 2)
+	a.Equal("HTTP GET", spans[1].Name())
+	a.NotEqual(traceId, spans[1].SpanContext().TraceID().String())
+}
@@ -6,6 +6,7 @@ package e
 import (
 	"go.uber.org/zap/zapcore"
 	"net/http"
+	"sort"
 )
 
 // Code 为业务错误码。注意：该错误码面向客户端，而非面向日志！
@@ -335,6 +336,18 @@ var codeDetails map[Code]CodeDetail = map[Code]CodeDetail{
 	},
 }
 
+// CodeDetails 返回所有业务错误码详情，按错误码升序排列
+func CodeDetails() []CodeDetail {
+	details := make([]CodeDetail, 0, len(codeDetails))
+	for _, detail := range codeDetails {
+		details = append(details, detail)
+	}
+	sort.Slice(details, func(i, j int) bool {
+		return details[i].Code < details[j].Code
+	})
+	return details
+}
+
 // GetCodeDetail 通过业务错误码 Code 获取对应的业务错误详情 CodeDetail
 func GetCodeDetail(code Code) CodeDetail {
 	return codeDetails[code]
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"project/app/pkg/health"
+	"project/app/pkg/sms"
+)
+
+// NewHealthRegistry 实例化健康检查注册表，并注册各组件的健康检查
+func NewHealthRegistry(cfg *health.Config, goCache *cache.Cache, aliyunLoginSms *sms.AliyunLoginSms, database *sql.DB) *health.Registry {
+	registry := health.NewRegistry(cfg)
+	registry.Register(health.Check{
+		Name: "cache",
+		Func: func(ctx context.Context) error {
+			const key = "health:check"
+			goCache.SetDefault(key, true)
+			if _, ok := goCache.Get(key); !ok {
+				return errors.New("go-cache read after write failed")
+			}
+			return nil
+		},
+	})
+	registry.Register(health.Check{
+		Name: "database",
+		Func: database.PingContext,
+	})
+	// 短信服务为多实例共用的外部服务，不可用时摘除实例并无帮助，因此为可选检查
+	registry.Register(health.Check{
+		Name:     "sms",
+		Func:     aliyunLoginSms.CheckHealth,
+		Optional: true,
+	})
+	return registry
+}
@@ -0,0 +1,348 @@
+package app
+
+import (
+	"crypto/tls"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"net"
+	"os"
+	"os/user"
+	"project/app/handler"
+	"project/app/pkg/config"
+	"project/app/pkg/systemd"
+	"project/app/pkg/tlsconfig"
+	"project/app/pkg/upgrade"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// schemeUnix 为 unix socket 监听地址前缀，例：unix:///run/app.sock
+	schemeUnix = "unix://"
+	// schemeSystemd 为 systemd socket activation 传递的 listener 地址前缀，后接 socket 名称（FileDescriptorName），
+	// 例：systemd://http
+	schemeSystemd = "systemd://"
+)
+
+// ListenerConfig 为单个 http 监听地址配置，对应配置文件中 `addr` 节点下的一项。
+//
+// 配置项可以是监听地址字符串（明文 http），也可以是包含 addr、tls 等字段的对象。监听地址支持以下形式：
+// - host:port：tcp 地址
+// - unix:///path/to/app.sock：unix socket
+// - systemd://name：systemd socket activation 传递的名为 name 的 socket
+type ListenerConfig struct {
+	// 监听地址，例：:443
+	Addr string `mapstructure:"addr" validate:"required"`
+	// TLS 配置，为空时使用明文 http
+	TLS *tlsconfig.Config `mapstructure:"tls"`
+	// unix socket 文件权限，例：0660，为 0 时由 umask 决定
+	Mode os.FileMode `mapstructure:"mode"`
+	// unix socket 文件所属用户（用户名或 uid），为空时不修改
+	Owner string `mapstructure:"owner"`
+	// unix socket 文件所属用户组（组名或 gid），为空时不修改
+	Group string `mapstructure:"group"`
+}
+
+type ListenerConfigs []ListenerConfig
+
+// NewListenerConfigs 从 viper 中读取 http 监听地址配置
+func NewListenerConfigs(v *viper.Viper) (ListenerConfigs, error) {
+	return unmarshalListenerConfigs(v, "addr")
+}
+
+// AdminListenerConfigs 为管理接口独立监听地址配置，对应配置文件中的 `adminAddr` 节点，格式同 `addr`
+type AdminListenerConfigs []ListenerConfig
+
+// NewAdminListenerConfigs 从 viper 中读取管理接口监听地址配置。
+//
+// 未配置管理令牌（admin.token）时，管理接口监听地址只能是 loopback 地址或 unix socket。
+func NewAdminListenerConfigs(v *viper.Viper, adminConfig *handler.AdminConfig) (AdminListenerConfigs, error) {
+	configs, err := unmarshalListenerConfigs(v, "adminAddr")
+	if err != nil {
+		return nil, err
+	}
+	if adminConfig.Token == "" {
+		for _, cfg := range configs {
+			if !cfg.isLoopback() {
+				return nil, errors.Errorf(
+					"adminAddr `%s` is neither a loopback address nor a unix socket, admin.token is required", cfg.Addr)
+			}
+		}
+	}
+	return AdminListenerConfigs(configs), nil
+}
+
+// unmarshalListenerConfigs 从 viper 中读取指定 key 下的监听地址配置并校验
+func unmarshalListenerConfigs(v *viper.Viper, key string) (ListenerConfigs, error) {
+	var configs ListenerConfigs
+	hook := mapstructure.ComposeDecodeHookFunc(
+		stringToListenerConfigHookFunc,
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	)
+	if err := config.Unmarshal(v, key, &configs, viper.DecodeHook(hook)); err != nil {
+		return nil, err
+	}
+	for _, cfg := range configs {
+		if err := cfg.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return configs, nil
+}
+
+// stringToListenerConfigHookFunc 将监听地址字符串转换为 ListenerConfig，兼容仅配置监听地址的写法
+func stringToListenerConfigHookFunc(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() != reflect.String || to != reflect.TypeOf(ListenerConfig{}) {
+		return data, nil
+	}
+	return ListenerConfig{Addr: data.(string)}, nil
+}
+
+// validate 校验监听地址配置
+func (cfg *ListenerConfig) validate() error {
+	switch {
+	case cfg.Addr == "":
+		return errors.New("addr is required for each http listener")
+	case strings.HasPrefix(cfg.Addr, schemeUnix):
+		if strings.TrimPrefix(cfg.Addr, schemeUnix) == "" {
+			return errors.Errorf("unix socket path is required in addr `%s`", cfg.Addr)
+		}
+		return nil
+	case strings.HasPrefix(cfg.Addr, schemeSystemd):
+		if strings.TrimPrefix(cfg.Addr, schemeSystemd) == "" {
+			return errors.Errorf("systemd socket name is required in addr `%s`", cfg.Addr)
+		}
+	}
+	if cfg.Mode != 0 || cfg.Owner != "" || cfg.Group != "" {
+		return errors.Errorf("mode, owner, group are only supported by unix socket, addr `%s`", cfg.Addr)
+	}
+	return nil
+}
+
+// isLoopback 判断监听地址是否仅接受本机连接：unix socket，或 host 为 localhost、loopback IP 的 tcp 地址
+func (cfg *ListenerConfig) isLoopback() bool {
+	switch {
+	case strings.HasPrefix(cfg.Addr, schemeUnix):
+		return true
+	case strings.HasPrefix(cfg.Addr, schemeSystemd):
+		// systemd 传递的 socket 无法从配置中得知实际监听地址
+		return false
+	}
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// listener 为已监听的 http 地址
+type listener struct {
+	// 对外提供服务的 listener，配置了 TLS 时为 TLS listener
+	net.Listener
+	// 配置中的监听地址
+	addr string
+	// 原始 listener，进程升级时传递其文件描述符
+	raw net.Listener
+	// 是否为管理接口监听地址（adminAddr）
+	admin bool
+}
+
+// listen 监听所有 http 地址，配置了 TLS 的地址使用 TLS listener。任一地址监听失败时关闭已监听的地址并返回错误。
+//
+// 优先使用继承的 listener：
+// - 进程由 Upgrade 启动时，使用父进程传递的同一监听地址的 listener
+// - 进程由 systemd socket activation 启动时，`systemd://name` 地址使用 systemd 传递的 listener
+// 未被任何地址使用的继承 listener 将被关闭。
+func listen(configs []ListenerConfig, zapLogger *zap.Logger) ([]listener, error) {
+	if len(configs) == 0 {
+		return nil, errors.New("at least one http address is required")
+	}
+	inherited, err := inheritedListeners()
+	if err != nil {
+		return nil, err
+	}
+
+	listeners := make([]listener, 0, len(configs))
+	closeAll := func() {
+		for _, l := range listeners {
+			_ = l.Close()
+		}
+		for _, ls := range inherited {
+			for _, l := range ls {
+				_ = l.Close()
+			}
+		}
+	}
+	for _, cfg := range configs {
+		var tlsConfig *tls.Config
+		if cfg.TLS != nil {
+			if tlsConfig, err = tlsconfig.New(cfg.TLS, zapLogger); err != nil {
+				closeAll()
+				return nil, errors.WithMessagef(err, "tls config for `%s` invalid", cfg.Addr)
+			}
+		}
+
+		var raw net.Listener
+		switch {
+		case len(inherited[cfg.Addr]) > 0:
+			raw, inherited[cfg.Addr] = inherited[cfg.Addr][0], inherited[cfg.Addr][1:]
+		case strings.HasPrefix(cfg.Addr, schemeUnix):
+			raw, err = listenUnix(cfg)
+		case strings.HasPrefix(cfg.Addr, schemeSystemd):
+			err = errors.Errorf("systemd socket `%s` not found, check LISTEN_FDNAMES or FileDescriptorName",
+				strings.TrimPrefix(cfg.Addr, schemeSystemd))
+		default:
+			if raw, err = net.Listen("tcp", cfg.Addr); err != nil {
+				err = errors.Wrapf(err, "listen on `%s` failed", cfg.Addr)
+			}
+		}
+		if err != nil {
+			closeAll()
+			return nil, err
+		}
+
+		l := listener{Listener: raw, addr: cfg.Addr, raw: raw}
+		if tlsConfig != nil {
+			l.Listener = tls.NewListener(raw, tlsConfig)
+		}
+		listeners = append(listeners, l)
+	}
+
+	for addr, ls := range inherited {
+		for _, l := range ls {
+			zapLogger.Warn("unused inherited listener closed", zap.String("addr", addr))
+			_ = l.Close()
+		}
+	}
+	return listeners, nil
+}
+
+// inheritedListeners 返回父进程（Upgrade）或 systemd 传递的 listener，key 为对应的监听地址
+func inheritedListeners() (map[string][]net.Listener, error) {
+	inherited := map[string][]net.Listener{}
+	upgradeListeners, err := upgrade.Inherited()
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range upgradeListeners {
+		inherited[l.Addr] = append(inherited[l.Addr], l.Listener)
+	}
+	systemdListeners, err := systemd.Listeners()
+	if err != nil {
+		for _, l := range upgradeListeners {
+			_ = l.Close()
+		}
+		return nil, err
+	}
+	for _, l := range systemdListeners {
+		addr := schemeSystemd + l.Name
+		inherited[addr] = append(inherited[addr], l.Listener)
+	}
+	return inherited, nil
+}
+
+// listenUnix 监听 unix socket，并设置 socket 文件的权限及所属用户、用户组
+func listenUnix(cfg ListenerConfig) (net.Listener, error) {
+	path := strings.TrimPrefix(cfg.Addr, schemeUnix)
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	uid, gid, err := lookupOwner(cfg.Owner, cfg.Group)
+	if err != nil {
+		return nil, err
+	}
+
+	// 关闭 listener 时自动删除 socket 文件
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listen on `%s` failed", cfg.Addr)
+	}
+	if cfg.Mode != 0 {
+		if err := os.Chmod(path, cfg.Mode); err != nil {
+			_ = listener.Close()
+			return nil, errors.Wrapf(err, "chmod unix socket `%s` failed", path)
+		}
+	}
+	if uid != -1 || gid != -1 {
+		if err := os.Chown(path, uid, gid); err != nil {
+			_ = listener.Close()
+			return nil, errors.Wrapf(err, "chown unix socket `%s` failed", path)
+		}
+	}
+	return listener, nil
+}
+
+// removeStaleSocket 删除上次运行残留的 socket 文件。socket 仍在被其他进程监听时返回错误。
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "stat unix socket `%s` failed", path)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return errors.Errorf("`%s` already exists and is not a unix socket", path)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		_ = conn.Close()
+		return errors.Errorf("unix socket `%s` is already in use", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return errors.Wrapf(err, "remove stale unix socket `%s` failed", path)
+	}
+	return nil
+}
+
+// lookupOwner 将用户名/uid、组名/gid 转换为 uid、gid，未设置时返回 -1
+func lookupOwner(owner, group string) (uid, gid int, err error) {
+	uid, err = lookupId(owner, func(name string) (string, error) {
+		u, err := user.Lookup(name)
+		if err != nil {
+			return "", err
+		}
+		return u.Uid, nil
+	})
+	if err != nil {
+		return 0, 0, errors.WithMessagef(err, "lookup unix socket owner `%s` failed", owner)
+	}
+	gid, err = lookupId(group, func(name string) (string, error) {
+		g, err := user.LookupGroup(name)
+		if err != nil {
+			return "", err
+		}
+		return g.Gid, nil
+	})
+	if err != nil {
+		return 0, 0, errors.WithMessagef(err, "lookup unix socket group `%s` failed", group)
+	}
+	return uid, gid, nil
+}
+
+// lookupId 将名称或数字 id 转换为数字 id，name 为空时返回 -1
+func lookupId(name string, lookup func(name string) (string, error)) (int, error) {
+	if name == "" {
+		return -1, nil
+	}
+	if id, err := strconv.Atoi(name); err == nil {
+		return id, nil
+	}
+	id, err := lookup(name)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil {
+		return 0, errors.Errorf("unsupported id `%s`", id)
+	}
+	return n, nil
+}
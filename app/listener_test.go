@@ -0,0 +1,243 @@
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"project/app/handler"
+	"project/app/pkg/tlsconfig"
+	"project/app/test/helper"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListen(t *testing.T) {
+	a := assert.New(t)
+	_, err := listen(nil, zap.NewNop())
+	a.NotNil(err)
+
+	listeners, err := listen([]ListenerConfig{{Addr: "127.0.0.1:0"}, {Addr: "127.0.0.1:0"}}, zap.NewNop())
+	a.Nil(err)
+	a.Len(listeners, 2)
+	_, err = listen([]ListenerConfig{{Addr: "127.0.0.1:0"}, {Addr: listeners[0].Addr().String()}}, zap.NewNop())
+	a.NotNil(err)
+	for _, listener := range listeners {
+		_ = listener.Close()
+	}
+}
+
+func TestNewListenerConfigs(t *testing.T) {
+	a := assert.New(t)
+	v := viper.New()
+	v.SetConfigType("yaml")
+	a.Nil(v.ReadConfig(strings.NewReader(`
+addr:
+  - ":80"
+  - addr: ":443"
+    tls:
+      certFile: cert.pem
+      keyFile: key.pem
+      cipherSuites: [TLS_AES_128_GCM_SHA256]
+      reloadInterval: 1m
+`)))
+	configs, err := NewListenerConfigs(v)
+	a.Nil(err)
+	a.Equal(ListenerConfigs{
+		{Addr: ":80"},
+		{Addr: ":443", TLS: &tlsconfig.Config{
+			CertFile:       "cert.pem",
+			KeyFile:        "key.pem",
+			CipherSuites:   []string{"TLS_AES_128_GCM_SHA256"},
+			ReloadInterval: time.Minute,
+		}},
+	}, configs)
+
+	// 兼容单个监听地址字符串
+	v = viper.New()
+	v.Set("addr", ":8080")
+	configs, err = NewListenerConfigs(v)
+	a.Nil(err)
+	a.Equal(ListenerConfigs{{Addr: ":8080"}}, configs)
+
+	v = viper.New()
+	v.SetConfigType("yaml")
+	a.Nil(v.ReadConfig(strings.NewReader(`
+addr:
+  - addr: unix:///run/app.sock
+    mode: 0660
+    owner: www-data
+    group: "33"
+  - systemd://http
+`)))
+	configs, err = NewListenerConfigs(v)
+	a.Nil(err)
+	a.Equal(ListenerConfigs{
+		{Addr: "unix:///run/app.sock", Mode: 0660, Owner: "www-data", Group: "33"},
+		{Addr: "systemd://http"},
+	}, configs)
+
+	invalid := []interface{}{
+		map[string]interface{}{"tls": map[string]interface{}{}},
+		"unix://",
+		"systemd://",
+		map[string]interface{}{"addr": ":80", "mode": "0660"},
+	}
+	for _, item := range invalid {
+		v = viper.New()
+		v.Set("addr", []interface{}{item})
+		_, err = NewListenerConfigs(v)
+		a.NotNil(err, "%v", item)
+	}
+}
+
+func TestNewAdminListenerConfigs(t *testing.T) {
+	a := assert.New(t)
+
+	// 未配置管理接口独立监听地址
+	configs, err := NewAdminListenerConfigs(viper.New(), &handler.AdminConfig{})
+	a.Nil(err)
+	a.Empty(configs)
+
+	// 未配置管理令牌时，仅允许 loopback 地址、unix socket
+	for _, addr := range []string{"127.0.0.1:9090", "localhost:9090", "[::1]:9090", "unix:///run/app-admin.sock"} {
+		v := viper.New()
+		v.Set("adminAddr", addr)
+		configs, err = NewAdminListenerConfigs(v, &handler.AdminConfig{})
+		a.Nil(err, addr)
+		a.Equal(AdminListenerConfigs{{Addr: addr}}, configs)
+	}
+	for _, addr := range []string{":9090", "0.0.0.0:9090", "10.0.0.1:9090", "systemd://admin"} {
+		v := viper.New()
+		v.Set("adminAddr", addr)
+		_, err = NewAdminListenerConfigs(v, &handler.AdminConfig{})
+		a.NotNil(err, addr)
+
+		_, err = NewAdminListenerConfigs(v, &handler.AdminConfig{Token: "secret"})
+		a.Nil(err, addr)
+	}
+}
+
+func TestListen_MutualTLS(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "listen")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+	certFile, keyFile, caFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "ca.pem")
+	ca := helper.NewCert(t, "ca", nil)
+	helper.NewCert(t, "server", ca).WriteFiles(t, certFile, keyFile)
+	a.Nil(ioutil.WriteFile(caFile, ca.CertPEM, 0600))
+
+	listeners, err := listen([]ListenerConfig{{
+		Addr: "127.0.0.1:0",
+		TLS:  &tlsconfig.Config{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile},
+	}}, zap.NewNop())
+	a.Nil(err)
+	url := "https://" + listeners[0].Addr().String()
+
+	app := newTestApp(&ServerConfig{ShutdownTimeout: time.Second})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.VerifiedChains[0][0].Subject.CommonName))
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- app.serve(ctx, handler, nil, listeners) }()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+	newClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs},
+		}}
+	}
+
+	// 未提供客户端证书时握手失败
+	_, err = newClient().Get(url)
+	a.NotNil(err)
+
+	// 提供非受信 CA 签发的客户端证书时握手失败
+	other := helper.NewCert(t, "other-ca", nil)
+	_, err = newClient(helper.NewCert(t, "client", other).TLSCertificate(t)).Get(url)
+	a.NotNil(err)
+
+	resp, err := newClient(helper.NewCert(t, "client", ca).TLSCertificate(t)).Get(url)
+	a.Nil(err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	a.Equal("client", string(body))
+
+	cancel()
+	a.Nil(<-serveErr)
+}
+
+func TestListen_Unix(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix socket file mode is not supported on windows")
+	}
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "listen")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.sock")
+
+	// 非 socket 文件不会被删除
+	a.Nil(ioutil.WriteFile(path, nil, 0600))
+	_, err = listen([]ListenerConfig{{Addr: schemeUnix + path}}, zap.NewNop())
+	a.NotNil(err)
+	a.Nil(os.Remove(path))
+
+	listeners, err := listen([]ListenerConfig{{
+		Addr:  schemeUnix + path,
+		Mode:  0600,
+		Owner: strconv.Itoa(os.Getuid()),
+		Group: strconv.Itoa(os.Getgid()),
+	}}, zap.NewNop())
+	a.Nil(err)
+	info, err := os.Stat(path)
+	a.Nil(err)
+	a.Equal(os.FileMode(0600), info.Mode().Perm())
+
+	// socket 正在使用时无法重复监听
+	_, err = listen([]ListenerConfig{{Addr: schemeUnix + path}}, zap.NewNop())
+	a.NotNil(err)
+
+	app := newTestApp(&ServerConfig{ShutdownTimeout: time.Second})
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- app.serve(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("ok"))
+		}), nil, listeners)
+	}()
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://unix/")
+	a.Nil(err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	a.Equal("ok", string(body))
+
+	cancel()
+	a.Nil(<-serveErr)
+	// 关闭后删除 socket 文件，下次启动可直接监听
+	_, err = os.Stat(path)
+	a.True(os.IsNotExist(err))
+}
+
+func TestListen_SystemdNotFound(t *testing.T) {
+	_, err := listen([]ListenerConfig{{Addr: "systemd://http"}}, zap.NewNop())
+	assert.NotNil(t, err)
+}
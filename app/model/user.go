@@ -0,0 +1,29 @@
+// 本包定义数据模型，每个模型对应一张数据库表
+
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+// User 用户，对应数据库表 users
+type User struct {
+	Id int64 `json:"id"`
+	// 用户名，唯一（不区分大小写）
+	Username string `json:"username"`
+	// 显示名称
+	DisplayName string `json:"display_name"`
+	// 邮箱，唯一（不区分大小写）
+	Email      string    `json:"email"`
+	CreateTime time.Time `json:"create_time"`
+	UpdateTime time.Time `json:"update_time"`
+}
+
+// UserResourceType 为用户的资源类型
+const UserResourceType = "user"
+
+// UserResourceName 返回用户的资源名称，例：users/1
+func UserResourceName(id int64) string {
+	return "users/" + strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,53 @@
+// 本包记录构建信息（版本号、VCS 修订版本、构建时间），构建时通过 -ldflags 注入，例：
+//
+//	go build -ldflags "-X project/app/pkg/buildinfo.Version=v1.2.0 \
+//	    -X project/app/pkg/buildinfo.Revision=$(git rev-parse HEAD) \
+//	    -X project/app/pkg/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/app
+
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// 构建时通过 -ldflags 注入
+var (
+	// 版本号，未注入时使用 go module 版本（go install 安装时有效）
+	Version string
+	// VCS 修订版本，例：git commit hash
+	Revision string
+	// 构建时间，例：2021-01-02T15:04:05Z
+	BuildTime string
+)
+
+// Info 为构建信息
+type Info struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+// Get 返回构建信息，未注入的字段为 unknown
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Revision:  Revision,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if info.Version == "" {
+		if buildInfo, ok := debug.ReadBuildInfo(); ok && buildInfo.Main.Version != "(devel)" {
+			info.Version = buildInfo.Main.Version
+		}
+	}
+	for _, field := range []*string{&info.Version, &info.Revision, &info.BuildTime} {
+		if *field == "" {
+			*field = "unknown"
+		}
+	}
+	return info
+}
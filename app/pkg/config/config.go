@@ -1,15 +1,57 @@
 package config
 
 import (
+	"fmt"
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
+	"io/ioutil"
+	"os"
+	"project/app/pkg/secret"
+	"sort"
+	"strings"
 )
 
 type FilePath string
 
+// EnvPrefix 为覆盖配置项的环境变量前缀
+const EnvPrefix = "APP"
+
+// envFileSuffix 为从文件读取配置值的环境变量后缀，例：APP_ADMIN_TOKEN_FILE=/run/secrets/admin_token
+const envFileSuffix = "_FILE"
+
+// Overrides 为通过命令行参数覆盖的配置项，每项格式为 `key=value`，例：`aliyunLoginSms.regionId=cn-hangzhou`。
+//
+// 实现了 flag.Value 接口，可直接用于可重复的命令行参数（如：`--set a=1 --set b=2`）。
+type Overrides []string
+
+func (overrides *Overrides) String() string {
+	return strings.Join(*overrides, ",")
+}
+
+func (overrides *Overrides) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return errors.Errorf("invalid override `%s`, expected key=value", value)
+	}
+	*overrides = append(*overrides, value)
+	return nil
+}
+
 // NewViper 实例化一个 *viper.Viper
-// 当传入多个文件路径时，获取的配置信息会依次向前覆盖，越靠后优先级越高。
-func NewViper(paths ...FilePath) (*viper.Viper, error) {
+//
+// 配置信息按以下顺序依次覆盖，越靠后优先级越高：
+//  1. 配置文件：当传入多个文件路径时，越靠后优先级越高；第一个文件（配置模板）必须存在，
+//     其余文件（如：secret.yaml）为可选，路径为空或文件不存在时忽略，此时机密配置可全部由环境变量提供
+//  2. 环境变量：配置项名称加 EnvPrefix 前缀，`.`、`-` 替换为 `_` 并转为大写，
+//     例：aliyunLoginSms.accessKeySecret -> APP_ALIYUNLOGINSMS_ACCESSKEYSECRET；
+//     环境变量名再加 `_FILE` 后缀时，从该环境变量指定的文件中读取配置值（去除末尾换行），适用于挂载的机密文件，
+//     例：APP_ALIYUNLOGINSMS_ACCESSKEYSECRET_FILE=/run/secrets/sms_secret。同一配置项不能同时设置两种环境变量
+//  3. overrides：命令行参数 `--set key=value`
+//
+// 以上任一来源的配置值均可为密文（`ENC[...]`，see: secret 包），读取时使用 SecretKeyEnv 环境变量提供的密钥透明解密。
+//
+// 环境变量只能覆盖配置文件中已存在的配置项（即：配置模板中定义的配置项），overrides 则不受此限制。
+// 环境变量、overrides 的值均为字符串，读取时按目标类型转换（如：`true` -> bool，`10s` -> time.Duration，`a,b` -> []string）。
+func NewViper(overrides Overrides, paths ...FilePath) (*viper.Viper, error) {
 	if len(paths) == 0 {
 		return nil, errors.New("at least one configuration is required")
 	}
@@ -17,6 +59,9 @@ func NewViper(paths ...FilePath) (*viper.Viper, error) {
 	v := viper.New()
 
 	for k, path := range paths {
+		if isMissingOptional(k, path) {
+			continue
+		}
 		v.SetConfigFile(string(path))
 		if k == 0 {
 			if err := v.ReadInConfig(); err != nil {
@@ -29,7 +74,164 @@ func NewViper(paths ...FilePath) (*viper.Viper, error) {
 		}
 	}
 
-	return v, nil
+	// viper 的 Set、AutomaticEnv 均无法覆盖 UnmarshalKey 读取的嵌套配置项，
+	// 因此在合并后的配置上应用覆盖项，再载入新的 *viper.Viper
+	settings := v.AllSettings()
+	for _, key := range v.AllKeys() {
+		value, ok, err := lookupEnv(key)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			setPath(settings, key, value)
+		}
+	}
+	for _, override := range overrides {
+		kv := strings.SplitN(override, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, errors.Errorf("invalid override `%s`, expected key=value", override)
+		}
+		setPath(settings, strings.ToLower(kv[0]), kv[1])
+	}
+	if err := decryptSettings(settings); err != nil {
+		return nil, err
+	}
+
+	result := viper.New()
+	if err := result.MergeConfigMap(settings); err != nil {
+		return nil, errors.Wrap(err, "merge config overrides failed")
+	}
+	return result, nil
+}
+
+// isMissingOptional 判断第 k 个配置文件是否为缺失的可选配置文件：除第一个外，路径为空或文件不存在
+func isMissingOptional(k int, path FilePath) bool {
+	if k == 0 {
+		return false
+	}
+	if path == "" {
+		return true
+	}
+	_, err := os.Stat(string(path))
+	return os.IsNotExist(err)
+}
+
+// EnvName 返回覆盖配置项 key 的环境变量名称，例：aliyunLoginSms.accessKeySecret -> APP_ALIYUNLOGINSMS_ACCESSKEYSECRET
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// lookupEnv 读取覆盖配置项 key 的环境变量，或 `_FILE` 后缀环境变量指定的文件内容
+func lookupEnv(key string) (value string, ok bool, err error) {
+	return lookupEnvName(EnvName(key))
+}
+
+// lookupEnvName 读取名为 name 的环境变量，或 `_FILE` 后缀环境变量指定的文件内容
+func lookupEnvName(name string) (value string, ok bool, err error) {
+	value, ok = os.LookupEnv(name)
+	file, fileOk := os.LookupEnv(name + envFileSuffix)
+	if !fileOk {
+		return value, ok, nil
+	}
+	if ok {
+		return "", false, errors.Errorf("both %s and %s are set", name, name+envFileSuffix)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", false, errors.Wrapf(err, "read %s failed", name+envFileSuffix)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// SecretKeyEnv 为解密配置值的密钥环境变量，值为以 `,` 分隔的 base64 编码密钥（第一个密钥用于加密，所有密钥均可用于解密），
+// 同样支持 `_FILE` 后缀，例：APP_CONFIG_KEY_FILE=/run/secrets/config_key
+const SecretKeyEnv = EnvPrefix + "_CONFIG_KEY"
+
+// LoadSecretKeys 从 SecretKeyEnv 环境变量读取密钥，未设置（或为空）时返回 nil
+func LoadSecretKeys() (secret.Keys, error) {
+	value, ok, err := lookupEnvName(SecretKeyEnv)
+	if err != nil || !ok || value == "" {
+		return nil, err
+	}
+	keys, err := secret.ParseKeys(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parse %s failed", SecretKeyEnv)
+	}
+	return keys, nil
+}
+
+// decryptSettings 解密配置中的所有密文，仅在存在密文时读取密钥
+func decryptSettings(settings map[string]interface{}) error {
+	var (
+		keys     secret.Keys
+		loaded   bool
+		problems []string
+	)
+	walkStrings(settings, "", func(path, value string) string {
+		if !secret.IsEncrypted(value) {
+			return value
+		}
+		if !loaded {
+			loaded = true
+			var err error
+			if keys, err = LoadSecretKeys(); err != nil {
+				problems = append(problems, err.Error())
+			}
+		}
+		if keys == nil {
+			problems = append(problems, fmt.Sprintf("`%s` is encrypted, but %s is not set", path, SecretKeyEnv))
+			return value
+		}
+		plaintext, err := keys.Decrypt(value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("decrypt `%s` failed: %s", path, err))
+			return value
+		}
+		return plaintext
+	})
+	if len(problems) > 0 {
+		// map 的遍历顺序不固定
+		sort.Strings(problems)
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// walkStrings 遍历嵌套 map、slice 中的所有字符串，并替换为 fn 的返回值
+func walkStrings(value interface{}, path string, fn func(path, value string) string) interface{} {
+	switch value := value.(type) {
+	case string:
+		return fn(path, value)
+	case map[string]interface{}:
+		for k, v := range value {
+			value[k] = walkStrings(v, joinPath(path, k), fn)
+		}
+	case map[interface{}]interface{}:
+		// 列表中的对象由 yaml 解析为 map[interface{}]interface{}
+		for k, v := range value {
+			value[k] = walkStrings(v, joinPath(path, fmt.Sprint(k)), fn)
+		}
+	case []interface{}:
+		for i, v := range value {
+			value[i] = walkStrings(v, fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+	}
+	return value
+}
+
+// setPath 设置嵌套 map 中 `.` 分隔的路径对应的值，路径中间节点不存在或不是 map 时创建新的 map
+func setPath(settings map[string]interface{}, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	m := settings
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[key] = next
+		}
+		m = next
+	}
+	m[keys[len(keys)-1]] = value
 }
 
 type IsDebug bool
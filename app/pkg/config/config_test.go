@@ -2,13 +2,18 @@ package config_test
 
 import (
 	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"project/app/pkg/config"
+	"project/app/pkg/secret"
 	"testing"
 )
 
 func TestNewViper(t *testing.T) {
 	a := assert.New(t)
 	v, err := config.NewViper(
+		nil,
 		"./testdata/config.yaml",
 		"./testdata/secret.yaml",
 	)
@@ -19,3 +24,133 @@ func TestNewViper(t *testing.T) {
 	a.Equal("mysql-secret", v.GetString("mysql.password"))
 	a.Equal("aliyun-secret-key", v.GetString("aliyun-secret-key"))
 }
+
+func TestNewViper_OptionalFiles(t *testing.T) {
+	a := assert.New(t)
+	setenv(t, "APP_MYSQL_PASSWORD", "env-secret")
+
+	// 仅有配置模板，机密配置由环境变量提供：可选配置文件路径为空或文件不存在时忽略
+	for _, secretFile := range []config.FilePath{"", "./testdata/not_exists.yaml"} {
+		v, err := config.NewViper(nil, "./testdata/config.yaml", secretFile)
+		a.Nil(err)
+		a.Equal("v1", v.GetString("version"))
+		a.Equal("env-secret", v.GetString("mysql.password"))
+	}
+
+	// 配置模板必须存在
+	_, err := config.NewViper(nil, "./testdata/not_exists.yaml")
+	a.NotNil(err)
+}
+
+func TestNewViper_Overrides(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "config")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+	secretFile := filepath.Join(dir, "mysql_password")
+	a.Nil(ioutil.WriteFile(secretFile, []byte("file-secret\n"), 0600))
+
+	setenv(t, "APP_LOGPATH", "/var/log/a.log")
+	setenv(t, "APP_MYSQL_PASSWORD_FILE", secretFile)
+	setenv(t, "APP_ALIYUN_SECRET_KEY", "env-secret-key")
+	// 配置文件中不存在的配置项不受环境变量影响
+	setenv(t, "APP_UNKNOWN", "x")
+
+	v, err := config.NewViper(
+		config.Overrides{"version=v3", "logpath=/tmp/a.log", "mysql.port=3306"},
+		"./testdata/config.yaml",
+		"./testdata/secret.yaml",
+	)
+	a.Nil(err)
+	// 命令行参数优先级最高
+	a.Equal("v3", v.GetString("version"))
+	a.Equal("/tmp/a.log", v.GetString("logpath"))
+	a.Equal(3306, v.GetInt("mysql.port"))
+	// 环境变量、_FILE 环境变量
+	a.Equal("file-secret", v.GetString("mysql.password"))
+	a.Equal("env-secret-key", v.GetString("aliyun-secret-key"))
+	a.False(v.IsSet("unknown"))
+
+	// 覆盖嵌套配置项时，保留同级的其他配置项
+	var mysql struct {
+		User     string
+		Password string
+		Port     int
+	}
+	a.Nil(v.UnmarshalKey("mysql", &mysql))
+	a.Equal("root", mysql.User)
+	a.Equal("file-secret", mysql.Password)
+	a.Equal(3306, mysql.Port)
+
+	// 同一配置项不能同时设置两种环境变量
+	setenv(t, "APP_MYSQL_PASSWORD", "env-secret")
+	_, err = config.NewViper(nil, "./testdata/config.yaml", "./testdata/secret.yaml")
+	a.NotNil(err)
+
+	_, err = config.NewViper(config.Overrides{"version"}, "./testdata/config.yaml")
+	a.NotNil(err)
+}
+
+func TestNewViper_Decrypt(t *testing.T) {
+	a := assert.New(t)
+	key, err := secret.GenerateKey()
+	a.Nil(err)
+	keys, err := secret.ParseKeys(key)
+	a.Nil(err)
+	password, err := keys.Encrypt("mysql-secret")
+	a.Nil(err)
+
+	dir, err := ioutil.TempDir("", "config")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+	secretFile := filepath.Join(dir, "secret.yaml")
+	a.Nil(ioutil.WriteFile(secretFile, []byte("mysql:\n  password: "+password+"\nhosts:\n  - "+password+"\n"), 0600))
+
+	// 未设置密钥
+	setenv(t, config.SecretKeyEnv, "")
+	_, err = config.NewViper(nil, "./testdata/config.yaml", config.FilePath(secretFile))
+	a.Equal([]string{
+		"`hosts[0]` is encrypted, but APP_CONFIG_KEY is not set",
+		"`mysql.password` is encrypted, but APP_CONFIG_KEY is not set",
+	}, config.Problems(err))
+
+	// 密钥不匹配
+	otherKey, err := secret.GenerateKey()
+	a.Nil(err)
+	setenv(t, config.SecretKeyEnv, otherKey)
+	_, err = config.NewViper(nil, "./testdata/config.yaml", config.FilePath(secretFile))
+	a.NotNil(err)
+
+	// 轮换密钥期间，新旧密钥均可解密
+	setenv(t, config.SecretKeyEnv, otherKey+","+key)
+	v, err := config.NewViper(nil, "./testdata/config.yaml", config.FilePath(secretFile))
+	a.Nil(err)
+	a.Equal("mysql-secret", v.GetString("mysql.password"))
+	a.Equal([]string{"mysql-secret"}, v.GetStringSlice("hosts"))
+	a.Equal("root", v.GetString("mysql.user"))
+}
+
+func TestOverrides_Set(t *testing.T) {
+	a := assert.New(t)
+	var overrides config.Overrides
+	a.Nil(overrides.Set("a.b=1"))
+	a.Nil(overrides.Set("c=x=y"))
+	a.NotNil(overrides.Set("d"))
+	a.Equal(config.Overrides{"a.b=1", "c=x=y"}, overrides)
+	a.Equal("APP_ALIYUNLOGINSMS_ACCESSKEYSECRET", config.EnvName("aliyunLoginSms.accessKeySecret"))
+}
+
+// setenv 设置环境变量，测试结束后恢复
+func setenv(t *testing.T, key, value string) {
+	old, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if ok {
+			_ = os.Setenv(key, old)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
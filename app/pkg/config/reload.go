@@ -0,0 +1,274 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ReloadConfig 为配置热加载配置，对应配置文件中的 `reload` 节点
+type ReloadConfig struct {
+	// 检查配置文件是否变更的间隔，为 0 时不自动检查（仍可通过管理接口触发重新加载）
+	Interval time.Duration `mapstructure:"interval" validate:"gte=0"`
+}
+
+// NewReloadConfig 从 viper 中读取配置热加载配置
+func NewReloadConfig(v *viper.Viper) (*ReloadConfig, error) {
+	cfg := &ReloadConfig{Interval: 10 * time.Second}
+	if err := Unmarshal(v, "reload", cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Snapshot 为一个版本的配置，加载后不再修改
+type Snapshot struct {
+	// 合并所有配置文件、环境变量、命令行参数后的配置
+	Viper *viper.Viper `json:"-"`
+	// 版本号，启动时为 1，每次重新加载成功后加 1
+	Version int64 `json:"version"`
+	// 所有配置文件内容的 sha256
+	Checksum string `json:"checksum"`
+	// 加载时间
+	LoadedAt time.Time `json:"loaded_at"`
+	// 相较上一版本变更的配置项
+	Changed []string `json:"changed,omitempty"`
+}
+
+// Subscriber 为配置变更的订阅者（各模块）：从新配置中读取并校验模块配置，返回应用该配置的 apply 函数。
+//
+// 所有订阅者均校验通过后，才依次调用 apply 函数；任一订阅者返回错误时，所有模块均继续使用原配置。
+// apply 函数不能失败，通常只是原子替换模块持有的配置结构体。
+type Subscriber func(v *viper.Viper) (apply func(), err error)
+
+// ReloadStatus 为配置热加载状态
+type ReloadStatus struct {
+	// 生效中的配置版本
+	Current *Snapshot `json:"current"`
+	// 最近一次重新加载失败的原因，重新加载成功后清空
+	LastError string `json:"last_error,omitempty"`
+	// 最近一次重新加载失败的时间
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// Reloader 负责配置热加载：配置文件变更（或通过管理接口触发）时，重新读取配置文件并按 Schema 校验，
+// 所有订阅者校验通过后原子替换为新版本的配置。
+//
+// 仅订阅者（如：日志级别）读取的配置项会在运行时生效，其它配置项的变更须重启后生效。
+type Reloader struct {
+	overrides Overrides
+	paths     []FilePath
+	schema    Schema
+	zapLogger *zap.Logger
+
+	current atomic.Value // *Snapshot
+
+	mu             sync.Mutex
+	subscribers    []Subscriber
+	lastError      error
+	lastErrorAt    time.Time
+	failedChecksum string // 最近一次加载失败的配置文件 checksum，配置文件再次变更前不再自动重试
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewReloader 实例化一个 *Reloader，v 为启动时读取的配置（版本 1），overrides、paths 与读取 v 时相同
+func NewReloader(v *viper.Viper, overrides Overrides, paths []FilePath, schema Schema, zapLogger *zap.Logger) (*Reloader, error) {
+	checksum, err := fileChecksum(paths)
+	if err != nil {
+		return nil, err
+	}
+	reloader := &Reloader{
+		overrides: overrides,
+		paths:     paths,
+		schema:    schema,
+		zapLogger: zapLogger.Named("config"),
+	}
+	reloader.current.Store(&Snapshot{Viper: v, Version: 1, Checksum: checksum, LoadedAt: time.Now()})
+	return reloader, nil
+}
+
+// Current 返回生效中的配置
+func (r *Reloader) Current() *Snapshot {
+	return r.current.Load().(*Snapshot)
+}
+
+// Status 返回配置热加载状态
+func (r *Reloader) Status() ReloadStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status := ReloadStatus{Current: r.Current()}
+	if r.lastError != nil {
+		lastErrorAt := r.lastErrorAt
+		status.LastError = r.lastError.Error()
+		status.LastErrorAt = &lastErrorAt
+	}
+	return status
+}
+
+// Subscribe 订阅配置变更，须在 Watch 之前调用
+func (r *Reloader) Subscribe(subscriber Subscriber) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subscribers = append(r.subscribers, subscriber)
+}
+
+// Reload 重新加载配置：配置文件内容未变更时直接返回生效中的配置；
+// 新配置校验失败时返回错误（*ValidationError 列出所有问题），继续使用原配置。
+func (r *Reloader) Reload() (*Snapshot, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	checksum, err := fileChecksum(r.paths)
+	if err != nil {
+		return nil, r.failed(checksum, err)
+	}
+	current := r.Current()
+	if checksum == current.Checksum {
+		r.lastError = nil
+		return current, nil
+	}
+
+	v, err := NewViper(r.overrides, r.paths...)
+	if err != nil {
+		return nil, r.failed(checksum, err)
+	}
+	if err := Validate(v, r.schema); err != nil {
+		return nil, r.failed(checksum, err)
+	}
+	var (
+		problems []string
+		applies  []func()
+	)
+	for _, subscriber := range r.subscribers {
+		apply, err := subscriber(v)
+		if err != nil {
+			problems = append(problems, Problems(err)...)
+			continue
+		}
+		if apply != nil {
+			applies = append(applies, apply)
+		}
+	}
+	if len(problems) > 0 {
+		return nil, r.failed(checksum, &ValidationError{Problems: problems})
+	}
+
+	for _, apply := range applies {
+		apply()
+	}
+	snapshot := &Snapshot{
+		Viper:    v,
+		Version:  current.Version + 1,
+		Checksum: checksum,
+		LoadedAt: time.Now(),
+		Changed:  changedKeys(current.Viper, v),
+	}
+	r.current.Store(snapshot)
+	r.lastError = nil
+	r.failedChecksum = ""
+	r.zapLogger.Info("config reloaded",
+		zap.Int64("version", snapshot.Version),
+		zap.String("checksum", snapshot.Checksum),
+		zap.Strings("changed", snapshot.Changed),
+	)
+	return snapshot, nil
+}
+
+// failed 记录重新加载失败的原因
+func (r *Reloader) failed(checksum string, err error) error {
+	r.lastError = err
+	r.lastErrorAt = time.Now()
+	r.failedChecksum = checksum
+	r.zapLogger.Error("config reload failed", zap.Error(err))
+	return err
+}
+
+// Watch 每隔 interval 检查一次配置文件内容，变更后重新加载配置，直到调用 Close。
+//
+// 加载失败的配置文件在再次变更前不会重试，以免重复记录错误日志。
+func (r *Reloader) Watch(interval time.Duration) {
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+			checksum, err := fileChecksum(r.paths)
+			if err != nil {
+				// 配置文件替换过程中可能短暂不可读，下次检查时重试
+				r.zapLogger.Warn("config file checksum failed", zap.Error(err))
+				continue
+			}
+			r.mu.Lock()
+			skip := checksum == r.Current().Checksum || checksum == r.failedChecksum
+			r.mu.Unlock()
+			if !skip {
+				_, _ = r.Reload()
+			}
+		}
+	}()
+}
+
+// Close 停止检查配置文件变更
+func (r *Reloader) Close() {
+	if r.stop == nil {
+		return
+	}
+	close(r.stop)
+	<-r.done
+}
+
+// fileChecksum 计算所有配置文件内容的 sha256
+func fileChecksum(paths []FilePath) (string, error) {
+	hash := sha256.New()
+	for k, path := range paths {
+		// 缺失的可选配置文件视同空文件，文件创建后即可检测到变更
+		if isMissingOptional(k, path) {
+			sum := sha256.Sum256(nil)
+			hash.Write(sum[:])
+			continue
+		}
+		data, err := ioutil.ReadFile(string(path))
+		if err != nil {
+			return "", errors.Wrapf(err, "read config file `%s` failed", path)
+		}
+		sum := sha256.Sum256(data)
+		hash.Write(sum[:])
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// changedKeys 返回两个版本的配置中值不同的配置项
+func changedKeys(previous, current *viper.Viper) []string {
+	keys := map[string]struct{}{}
+	for _, key := range previous.AllKeys() {
+		keys[key] = struct{}{}
+	}
+	for _, key := range current.AllKeys() {
+		keys[key] = struct{}{}
+	}
+	changed := make([]string, 0)
+	for key := range keys {
+		if !reflect.DeepEqual(previous.Get(key), current.Get(key)) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
@@ -0,0 +1,109 @@
+package config_test
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"project/app/pkg/config"
+	"testing"
+	"time"
+)
+
+func TestReloader(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "reload")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	a.Nil(ioutil.WriteFile(file, []byte("test: {name: a, timeout: 1s}\n"), 0600))
+
+	paths := []config.FilePath{config.FilePath(file)}
+	v, err := config.NewViper(nil, paths...)
+	a.Nil(err)
+	schema := config.Schema{
+		"test": func(v *viper.Viper) error {
+			return config.Unmarshal(v, "test", &testConfig{})
+		},
+	}
+	reloader, err := config.NewReloader(v, nil, paths, schema, zap.NewNop())
+	a.Nil(err)
+	var applied []string
+	reloader.Subscribe(func(v *viper.Viper) (func(), error) {
+		name := v.GetString("test.name")
+		if name == "reject" {
+			return nil, errors.New("name `reject` is not allowed")
+		}
+		return func() { applied = append(applied, name) }, nil
+	})
+
+	// 配置文件未变更
+	snapshot, err := reloader.Reload()
+	a.Nil(err)
+	a.Equal(int64(1), snapshot.Version)
+	a.Empty(applied)
+
+	a.Nil(ioutil.WriteFile(file, []byte("test: {name: b, timeout: 1s}\n"), 0600))
+	snapshot, err = reloader.Reload()
+	a.Nil(err)
+	a.Equal(int64(2), snapshot.Version)
+	a.Equal([]string{"test.name"}, snapshot.Changed)
+	a.Equal([]string{"b"}, applied)
+	a.Equal("b", reloader.Current().Viper.GetString("test.name"))
+
+	// 校验失败、订阅者拒绝时继续使用原配置
+	for _, content := range []string{"test: {name: c, timeout: 0s}\n", "test: {name: reject, timeout: 1s}\n"} {
+		a.Nil(ioutil.WriteFile(file, []byte(content), 0600))
+		_, err = reloader.Reload()
+		a.NotNil(err)
+		status := reloader.Status()
+		a.Equal(int64(2), status.Current.Version)
+		a.Equal(err.Error(), status.LastError)
+		a.NotNil(status.LastErrorAt)
+	}
+	a.Equal([]string{"b"}, applied)
+
+	// 检查配置文件变更后自动重新加载
+	reloader.Watch(10 * time.Millisecond)
+	defer reloader.Close()
+	a.Nil(ioutil.WriteFile(file, []byte("test: {name: d, timeout: 1s}\n"), 0600))
+	a.Eventually(func() bool {
+		return reloader.Current().Viper.GetString("test.name") == "d"
+	}, time.Second, 10*time.Millisecond)
+	a.Empty(reloader.Status().LastError)
+}
+
+func TestReloader_OptionalFile(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "reload")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	secretFile := filepath.Join(dir, "secret.yaml")
+	a.Nil(ioutil.WriteFile(file, []byte("test: {name: a, timeout: 1s}\n"), 0600))
+
+	// 可选的机密配置文件不存在
+	paths := []config.FilePath{config.FilePath(file), config.FilePath(secretFile)}
+	v, err := config.NewViper(nil, paths...)
+	a.Nil(err)
+	schema := config.Schema{
+		"test": func(v *viper.Viper) error {
+			return config.Unmarshal(v, "test", &testConfig{})
+		},
+	}
+	reloader, err := config.NewReloader(v, nil, paths, schema, zap.NewNop())
+	a.Nil(err)
+	snapshot, err := reloader.Reload()
+	a.Nil(err)
+	a.Equal(int64(1), snapshot.Version)
+
+	// 创建后即可检测到变更
+	a.Nil(ioutil.WriteFile(secretFile, []byte("test: {name: b}\n"), 0600))
+	snapshot, err = reloader.Reload()
+	a.Nil(err)
+	a.Equal(int64(2), snapshot.Version)
+	a.Equal("b", snapshot.Viper.GetString("test.name"))
+}
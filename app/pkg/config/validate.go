@@ -0,0 +1,242 @@
+package config
+
+import (
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ValidationError 为配置校验错误，列出所有问题（而非仅第一个），便于一次性修正配置
+type ValidationError struct {
+	Problems []string
+}
+
+func (err *ValidationError) Error() string {
+	return "invalid config:\n  - " + strings.Join(err.Problems, "\n  - ")
+}
+
+// Problems 返回 err 中的问题列表：*ValidationError 返回其列出的所有问题，其它错误作为一个问题，err 为 nil 时返回 nil
+func Problems(err error) []string {
+	if err == nil {
+		return nil
+	}
+	if ve, ok := errors.Cause(err).(*ValidationError); ok {
+		return ve.Problems
+	}
+	return []string{err.Error()}
+}
+
+// validate 为配置校验使用的验证器，校验规则见结构体字段的 `validate` 标签，
+// 错误信息中的字段名称使用 `mapstructure` 标签（即：配置项名称）
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("mapstructure"), ",", 2)[0]
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	return v
+}
+
+// Unmarshal 将配置节点 key 解码到 cfg，并校验：
+//  1. 未知配置项：配置节点中存在、但 cfg 中未定义的配置项（如：拼写错误）
+//  2. 类型错误：配置值无法转换为字段类型
+//  3. `validate` 标签定义的校验规则，see: github.com/go-playground/validator
+//
+// cfg 须为结构体指针或结构体切片指针，可预先填充默认值。发现问题时返回 *ValidationError，列出该节点的所有问题。
+// 由其它模块读取的子节点，使用 IgnoreKeys 排除。
+func Unmarshal(v *viper.Viper, key string, cfg interface{}, opts ...viper.DecoderConfigOption) error {
+	opts = append(opts, func(c *mapstructure.DecoderConfig) {
+		c.ErrorUnused = true
+	})
+	// 解码出错时，其余字段仍已解码（出错的字段保持原值），继续校验以列出所有问题
+	var problems []string
+	if err := v.UnmarshalKey(key, cfg, opts...); err != nil {
+		problems = decodeProblems(key, err)
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(cfg))
+	switch value.Kind() {
+	case reflect.Struct:
+		problems = append(problems, validateProblems(key, value.Interface())...)
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			if reflect.Indirect(value.Index(i)).Kind() == reflect.Struct {
+				problems = append(problems, validateProblems(fmt.Sprintf("%s[%d]", key, i), value.Index(i).Interface())...)
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// IgnoreKeys 返回一个解码选项：解码时跳过配置节点下名称为 names 的子节点（不区分大小写），
+// 用于由其它模块单独读取、校验的子节点，例：`log.capture` 由 handler.NewBodyCaptureConfig 读取。
+//
+// 仅作用于配置节点本身（cfg 对应的结构体），不作用于更深层的子节点。
+func IgnoreKeys(names ...string) viper.DecoderConfigOption {
+	return func(c *mapstructure.DecoderConfig) {
+		root := true
+		ignore := func(from, to reflect.Type, data interface{}) (interface{}, error) {
+			settings, ok := data.(map[string]interface{})
+			if !root || !ok || to.Kind() != reflect.Struct {
+				return data, nil
+			}
+			root = false
+			result := make(map[string]interface{}, len(settings))
+			for key, value := range settings {
+				result[key] = value
+			}
+			for _, name := range names {
+				for key := range result {
+					if strings.EqualFold(key, name) {
+						delete(result, key)
+					}
+				}
+			}
+			return result, nil
+		}
+		if c.DecodeHook == nil {
+			c.DecodeHook = ignore
+		} else {
+			c.DecodeHook = mapstructure.ComposeDecodeHookFunc(ignore, c.DecodeHook)
+		}
+	}
+}
+
+var (
+	// mapstructure 的未知配置项错误信息，例：'rotate' has invalid keys: maxsise, compres
+	invalidKeysPattern = regexp.MustCompile(`^'([^']*)' has invalid keys: (.*)$`)
+	// mapstructure 错误信息中的字段名称，例：error decoding 'readTimeout': time: invalid duration "10"
+	fieldNamePattern = regexp.MustCompile(`'([^']*)'`)
+)
+
+// decodeProblems 将解码错误转换为问题列表，问题中的配置项均为完整路径
+func decodeProblems(key string, err error) []string {
+	messages := []string{err.Error()}
+	if me, ok := err.(*mapstructure.Error); ok {
+		messages = me.Errors
+	}
+	problems := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		if match := invalidKeysPattern.FindStringSubmatch(msg); match != nil {
+			for _, name := range strings.Split(match[2], ", ") {
+				problems = append(problems, fmt.Sprintf("unknown config key `%s`", joinPath(joinPath(key, match[1]), name)))
+			}
+			continue
+		}
+		if loc := fieldNamePattern.FindStringSubmatchIndex(msg); loc != nil {
+			path := joinPath(key, msg[loc[2]:loc[3]])
+			problems = append(problems, msg[:loc[0]]+"`"+path+"`"+msg[loc[1]:])
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("`%s` %s", key, msg))
+	}
+	return problems
+}
+
+// validateProblems 按 `validate` 标签校验结构体，并将校验错误转换为问题列表
+func validateProblems(key string, s interface{}) []string {
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+	fieldErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return []string{fmt.Sprintf("`%s` %s", key, err)}
+	}
+	problems := make([]string, 0, len(fieldErrors))
+	for _, fe := range fieldErrors {
+		// 去掉命名空间中的结构体类型名称，例：Config.rotate.maxSize -> rotate.maxSize
+		path := fe.Namespace()
+		if i := strings.Index(path, "."); i >= 0 {
+			path = path[i+1:]
+		}
+		path = joinPath(key, path)
+		problems = append(problems, fmt.Sprintf("`%s` %s", path, describe(fe, path)))
+	}
+	return problems
+}
+
+// describe 返回校验错误的描述，path 为出错配置项的完整路径
+func describe(fe validator.FieldError, path string) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_with":
+		// 参数为同级结构体字段名称，转换为配置项路径，例：log.sampling.thereafter、Initial -> log.sampling.initial
+		sibling := path[:strings.LastIndex(path, ".")+1] + strings.ToLower(fe.Param()[:1]) + fe.Param()[1:]
+		return fmt.Sprintf("is required when `%s` is set", sibling)
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s], got `%v`", strings.Replace(fe.Param(), " ", ", ", -1), fe.Value())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s, got %v", fe.Param(), fe.Value())
+	case "gte", "min":
+		return fmt.Sprintf("must be greater than or equal to %s, got %v", fe.Param(), fe.Value())
+	case "lte", "max":
+		return fmt.Sprintf("must be less than or equal to %s, got %v", fe.Param(), fe.Value())
+	default:
+		return fmt.Sprintf("failed on the `%s` rule", fe.Tag())
+	}
+}
+
+// joinPath 拼接配置项路径，例：(log, rotate) -> log.rotate，(addr, [0].tls) -> addr[0].tls
+func joinPath(key, name string) string {
+	switch {
+	case name == "":
+		return key
+	case key == "" || strings.HasPrefix(name, "["):
+		return key + name
+	default:
+		return key + "." + name
+	}
+}
+
+// Schema 定义所有顶层配置节点：key 为节点名称，value 为读取并校验该节点的函数（通常调用各模块读取配置的 provider）
+type Schema map[string]func(v *viper.Viper) error
+
+// Validate 按 schema 校验 viper 中的所有配置，汇总所有问题后返回 *ValidationError：
+// 未在 schema 中定义的顶层配置节点，以及各节点的读取、校验错误。
+func Validate(v *viper.Viper, schema Schema) error {
+	known := make(map[string]bool, len(schema))
+	keys := make([]string, 0, len(schema))
+	for key := range schema {
+		known[strings.ToLower(key)] = true
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var problems []string
+	settings := v.AllSettings()
+	var unknown []string
+	for key := range settings {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		problems = append(problems, fmt.Sprintf("unknown config key `%s`", key))
+	}
+
+	for _, key := range keys {
+		problems = append(problems, Problems(schema[key](v))...)
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package config_test
+
+import (
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"project/app/pkg/config"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Name     string        `mapstructure:"name" validate:"required"`
+	Timeout  time.Duration `mapstructure:"timeout" validate:"gt=0"`
+	Level    string        `mapstructure:"level" validate:"omitempty,oneof=debug info"`
+	Children []testChild   `mapstructure:"children" validate:"dive"`
+}
+
+type testChild struct {
+	Size int `mapstructure:"size" validate:"gte=0"`
+}
+
+func newTestViper(t *testing.T, content string) *viper.Viper {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	assert.Nil(t, v.ReadConfig(strings.NewReader(content)))
+	return v
+}
+
+func TestUnmarshal(t *testing.T) {
+	a := assert.New(t)
+	v := newTestViper(t, `
+test:
+  name: a
+  level: debug
+  children:
+    - size: 1
+`)
+	cfg := &testConfig{Timeout: time.Second}
+	a.Nil(config.Unmarshal(v, "test", cfg))
+	a.Equal(&testConfig{Name: "a", Timeout: time.Second, Level: "debug", Children: []testChild{{Size: 1}}}, cfg)
+
+	// 列出所有问题：未知配置项、类型错误、校验规则
+	v = newTestViper(t, `
+test:
+  timeout: abc
+  level: trace
+  lable: x
+  children:
+    - size: -1
+      colour: red
+`)
+	err := config.Unmarshal(v, "test", &testConfig{})
+	a.IsType(&config.ValidationError{}, err)
+	a.ElementsMatch([]string{
+		"unknown config key `test.lable`",
+		"unknown config key `test.children[0].colour`",
+		"error decoding `test.timeout`: time: invalid duration \"abc\"",
+		"`test.name` is required",
+		"`test.timeout` must be greater than 0, got 0s",
+		"`test.level` must be one of [debug, info], got `trace`",
+		"`test.children[0].size` must be greater than or equal to 0, got -1",
+	}, config.Problems(err))
+
+	// 结构体切片
+	var children []testChild
+	err = config.Unmarshal(newTestViper(t, "children: [{size: 1}, {size: -1}]"), "children", &children)
+	a.Equal([]string{"`children[1].size` must be greater than or equal to 0, got -1"}, config.Problems(err))
+}
+
+func TestUnmarshal_IgnoreKeys(t *testing.T) {
+	a := assert.New(t)
+	v := newTestViper(t, `
+test:
+  name: a
+  timeout: 1s
+  capture: {enabled: true}
+  children:
+    - size: 1
+      capture: true
+`)
+	// 仅跳过配置节点本身的子节点，其它配置项照常解码、校验
+	cfg := &testConfig{}
+	err := config.Unmarshal(v, "test", cfg, config.IgnoreKeys("Capture"))
+	a.Equal([]string{"unknown config key `test.children[0].capture`"}, config.Problems(err))
+	a.Equal(time.Second, cfg.Timeout)
+
+	err = config.Unmarshal(v, "test", &testConfig{})
+	a.Contains(config.Problems(err), "unknown config key `test.capture`")
+}
+
+func TestValidate(t *testing.T) {
+	a := assert.New(t)
+	v := newTestViper(t, `
+test:
+  name: a
+  timeout: 1s
+tset:
+  name: b
+`)
+	schema := config.Schema{
+		"test": func(v *viper.Viper) error {
+			return config.Unmarshal(v, "test", &testConfig{})
+		},
+		"other": func(v *viper.Viper) error {
+			return nil
+		},
+	}
+	err := config.Validate(v, schema)
+	a.Equal([]string{"unknown config key `tset`"}, config.Problems(err))
+	a.Equal("invalid config:\n  - unknown config key `tset`", err.Error())
+
+	// 汇总所有配置节点的问题
+	v = newTestViper(t, "test: {name: a, timeout: 0s}\ntset: {name: b}")
+	a.Equal([]string{
+		"unknown config key `tset`",
+		"`test.timeout` must be greater than 0, got 0s",
+	}, config.Problems(config.Validate(v, schema)))
+
+	a.Nil(config.Validate(newTestViper(t, "test: {name: a, timeout: 1s}"), schema))
+}
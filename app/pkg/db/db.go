@@ -0,0 +1,101 @@
+// 本包用于数据库连接及迁移：根据配置实例化 *sql.DB，并按版本号依次执行以 Go 字符串定义的迁移（SQL 语句）。
+// 目前仅支持 sqlite（嵌入式数据库，无需单独部署），使用纯 Go 实现的驱动 modernc.org/sqlite，无需 cgo 即可交叉编译。
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	_ "modernc.org/sqlite"
+	"os"
+	"path/filepath"
+	"project/app/pkg/config"
+	"strings"
+	"time"
+)
+
+// Config 为数据库配置，对应配置文件中的 `db` 节点
+type Config struct {
+	// 数据库驱动，可选值：sqlite
+	Driver string `mapstructure:"driver" validate:"oneof=sqlite"`
+	// 数据源名称，sqlite 为数据库文件路径及参数，例：file:./runtime/app.db?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)
+	DSN string `mapstructure:"dsn" validate:"required"`
+	// 最大连接数，为 0 时不限制
+	MaxOpenConns int `mapstructure:"maxOpenConns" validate:"gte=0"`
+	// 最大空闲连接数
+	MaxIdleConns int `mapstructure:"maxIdleConns" validate:"gte=0"`
+	// 连接最长使用时间，为 0 时不限制
+	ConnMaxLifetime time.Duration `mapstructure:"connMaxLifetime" validate:"gte=0"`
+	// 启动时是否自动执行未执行的迁移，为 false 时须通过 `console db migrate` 执行
+	AutoMigrate bool `mapstructure:"autoMigrate"`
+}
+
+// NewConfig 从 viper 中读取数据库配置
+func NewConfig(v *viper.Viper) (*Config, error) {
+	cfg := &Config{Driver: "sqlite", MaxIdleConns: 2}
+	if err := config.Unmarshal(v, "db", cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// NewUnconnectedDB 实例化一个未建立连接的 *sql.DB：不创建数据库目录、不检查连接、不执行迁移，
+// 用于仅需构建对象、不访问数据库的场景（如：console routes）
+func NewUnconnectedDB(cfg *Config) (*sql.DB, func(), error) {
+	db, err := sql.Open(cfg.Driver, cfg.DSN)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "open database failed")
+	}
+	return db, func() { _ = db.Close() }, nil
+}
+
+// NewDB 实例化一个 *sql.DB 并检查连接，cfg.AutoMigrate 为 true 时执行未执行的迁移
+func NewDB(cfg *Config, migrations Migrations, zapLogger *zap.Logger) (*sql.DB, func(), error) {
+	if path := sqliteFile(cfg.DSN); path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, nil, errors.Wrap(err, "create database directory failed")
+		}
+	}
+	db, err := sql.Open(cfg.Driver, cfg.DSN)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "open database failed")
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	cleanup := func() {
+		if err := db.Close(); err != nil {
+			zapLogger.Error("close database failed", zap.Error(err))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		cleanup()
+		return nil, nil, errors.Wrap(err, "ping database failed")
+	}
+	if cfg.AutoMigrate {
+		applied, err := Migrate(ctx, db, migrations)
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		for _, m := range applied {
+			zapLogger.Info("database migration applied", zap.Int("version", m.Version), zap.String("name", m.Name))
+		}
+	}
+	return db, cleanup, nil
+}
+
+// sqliteFile 返回 sqlite 数据源名称中的数据库文件路径，内存数据库返回空字符串
+func sqliteFile(dsn string) string {
+	path := strings.TrimPrefix(strings.SplitN(dsn, "?", 2)[0], "file:")
+	if path == "" || path == ":memory:" || strings.HasPrefix(dsn, "file::memory:") {
+		return ""
+	}
+	return path
+}
@@ -0,0 +1,104 @@
+package db_test
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"project/app/pkg/db"
+	"testing"
+)
+
+var testMigrations = db.Migrations{
+	{Version: 1, Name: "create_a", Up: "CREATE TABLE a (id INTEGER PRIMARY KEY); CREATE INDEX a_id ON a (id);"},
+	{Version: 2, Name: "create_b", Up: "CREATE TABLE b (id INTEGER PRIMARY KEY)"},
+}
+
+func TestNewDB(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "db")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+
+	// 自动创建数据库文件所在目录、执行迁移
+	cfg := &db.Config{
+		Driver:      "sqlite",
+		DSN:         "file:" + filepath.Join(dir, "data", "app.db") + "?_pragma=foreign_keys(1)",
+		AutoMigrate: true,
+	}
+	database, cleanup, err := db.NewDB(cfg, testMigrations, zap.NewNop())
+	a.Nil(err)
+	defer cleanup()
+	pending, err := db.Pending(context.Background(), database, testMigrations)
+	a.Nil(err)
+	a.Empty(pending)
+	_, err = database.Exec("INSERT INTO b (id) VALUES (1)")
+	a.Nil(err)
+}
+
+func TestMigrate(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+	// 内存数据库随连接关闭而销毁，因此只使用一个连接并保持空闲
+	cfg := &db.Config{Driver: "sqlite", DSN: "file::memory:", MaxOpenConns: 1, MaxIdleConns: 1}
+	database, cleanup, err := db.NewDB(cfg, nil, zap.NewNop())
+	a.Nil(err)
+	defer cleanup()
+
+	applied, err := db.Migrate(ctx, database, testMigrations[:1])
+	a.Nil(err)
+	a.Len(applied, 1)
+	pending, err := db.Pending(ctx, database, testMigrations)
+	a.Nil(err)
+	a.Equal(testMigrations[1:], db.Migrations(pending))
+
+	// 失败的迁移回滚，之前的迁移保留
+	migrations := append(testMigrations, db.Migration{
+		Version: 3,
+		Name:    "broken",
+		Up:      "CREATE TABLE c (id INTEGER PRIMARY KEY); INSERT INTO unknown VALUES (1);",
+	})
+	applied, err = db.Migrate(ctx, database, migrations)
+	a.NotNil(err)
+	a.Len(applied, 1)
+	a.Equal(2, applied[0].Version)
+	_, err = database.Exec("SELECT * FROM c")
+	a.NotNil(err)
+
+	applied, err = db.Migrate(ctx, database, testMigrations)
+	a.Nil(err)
+	a.Empty(applied)
+
+	// 版本号须递增
+	_, err = db.Migrate(ctx, database, db.Migrations{testMigrations[1], testMigrations[0]})
+	a.NotNil(err)
+}
+
+func TestUniqueViolation(t *testing.T) {
+	a := assert.New(t)
+	cfg := &db.Config{Driver: "sqlite", DSN: "file::memory:", MaxOpenConns: 1, MaxIdleConns: 1}
+	database, cleanup, err := db.NewDB(cfg, nil, zap.NewNop())
+	a.Nil(err)
+	defer cleanup()
+	_, err = database.Exec("CREATE TABLE u (name TEXT UNIQUE, a TEXT, b TEXT, UNIQUE (a, b))")
+	a.Nil(err)
+	_, err = database.Exec("INSERT INTO u (name, a, b) VALUES ('x', '1', '2')")
+	a.Nil(err)
+
+	_, err = database.Exec("INSERT INTO u (name, a, b) VALUES ('x', '3', '4')")
+	column, ok := db.UniqueViolation(errors.Wrap(err, "insert failed"))
+	a.True(ok)
+	a.Equal("name", column)
+	// 联合唯一约束取第一列
+	_, err = database.Exec("INSERT INTO u (name, a, b) VALUES ('y', '1', '2')")
+	column, ok = db.UniqueViolation(err)
+	a.True(ok)
+	a.Equal("a", column)
+
+	_, err = database.Exec("INSERT INTO unknown VALUES (1)")
+	_, ok = db.UniqueViolation(err)
+	a.False(ok)
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"github.com/pkg/errors"
+	"regexp"
+	"strings"
+)
+
+// sqliteConstraintUnique 为 sqlite 扩展错误码 SQLITE_CONSTRAINT_UNIQUE
+const sqliteConstraintUnique = 2067
+
+// codeError 为携带 sqlite 扩展错误码的驱动错误，如：modernc.org/sqlite 的 *sqlite.Error。
+// 通过接口判断，调用方无需依赖具体驱动的错误类型
+type codeError interface {
+	error
+	Code() int
+}
+
+// uniqueColumnPattern 匹配 sqlite 唯一约束错误信息中的列，例：UNIQUE constraint failed: users.username
+var uniqueColumnPattern = regexp.MustCompile(`UNIQUE constraint failed: ([^\s,()]+)`)
+
+// UniqueViolation 判断 err 是否为违反唯一约束的错误，是则返回违反约束的列名（联合唯一约束时取第一列）
+func UniqueViolation(err error) (column string, ok bool) {
+	var ce codeError
+	if !errors.As(err, &ce) || ce.Code() != sqliteConstraintUnique {
+		return "", false
+	}
+	if match := uniqueColumnPattern.FindStringSubmatch(ce.Error()); match != nil {
+		column = match[1]
+		if i := strings.LastIndex(column, "."); i >= 0 {
+			column = column[i+1:]
+		}
+	}
+	return column, true
+}
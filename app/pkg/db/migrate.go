@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// Migration 为一次数据库迁移
+type Migration struct {
+	// 版本号，须唯一且递增，已执行的迁移记录在 schema_migrations 表中
+	Version int
+	// 名称，例：create_users
+	Name string
+	// 迁移 SQL，可包含多条语句，与版本记录在同一事务中执行
+	Up string
+}
+
+// Migrations 为按版本号升序排列的所有迁移
+type Migrations []Migration
+
+// createMigrationsTable 创建记录已执行迁移的表
+const createMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version    INTEGER PRIMARY KEY,
+	name       TEXT NOT NULL,
+	applied_at TIMESTAMP NOT NULL
+)`
+
+// Migrate 依次执行未执行的迁移，返回本次执行的迁移；某个迁移失败时，该迁移回滚，已执行的迁移保留
+func Migrate(ctx context.Context, db *sql.DB, migrations Migrations) ([]Migration, error) {
+	pending, err := Pending(ctx, db, migrations)
+	if err != nil {
+		return nil, err
+	}
+	applied := make([]Migration, 0, len(pending))
+	for _, m := range pending {
+		if err := apply(ctx, db, m); err != nil {
+			return applied, errors.Wrapf(err, "apply migration %d_%s failed", m.Version, m.Name)
+		}
+		applied = append(applied, m)
+	}
+	return applied, nil
+}
+
+// Pending 返回未执行的迁移
+func Pending(ctx context.Context, db *sql.DB, migrations Migrations) ([]Migration, error) {
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version <= migrations[i-1].Version {
+			return nil, errors.Errorf("migration versions must be increasing, got %d after %d",
+				migrations[i].Version, migrations[i-1].Version)
+		}
+	}
+	if _, err := db.ExecContext(ctx, createMigrationsTable); err != nil {
+		return nil, errors.Wrap(err, "create schema_migrations failed")
+	}
+	rows, err := db.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, errors.Wrap(err, "query schema_migrations failed")
+	}
+	defer rows.Close()
+	applied := map[int]bool{}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, errors.Wrap(err, "scan schema_migrations failed")
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "query schema_migrations failed")
+	}
+
+	pending := make([]Migration, 0)
+	for _, m := range migrations {
+		if !applied[m.Version] {
+			pending = append(pending, m)
+		}
+	}
+	return pending, nil
+}
+
+// apply 在事务中执行一个迁移并记录版本
+func apply(ctx context.Context, db *sql.DB, m Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, m.Up); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+		m.Version, m.Name, time.Now().UTC(),
+	); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
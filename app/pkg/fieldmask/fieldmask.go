@@ -0,0 +1,146 @@
+// 本包实现更新请求的 update_mask（谷歌 API 设计指南，see: https://google.aip.dev/134#update-masks、https://google.aip.dev/161）：
+// update_mask 为以 `,` 分隔的字段路径（与资源的 json 字段名称一致，嵌套字段以 `.` 连接），仅更新、校验其中的字段。
+
+package fieldmask
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Param 为请求参数名称
+const Param = "update_mask"
+
+// Error 为 update_mask 参数错误，应响应 e.CodeInvalidArgument 错误及 e.BadRequest 错误详情
+type Error struct {
+	// 出错的字段路径
+	Path string
+	// 错误原因
+	Reason string
+}
+
+func (err *Error) Error() string {
+	return fmt.Sprintf("%s中的`%s`：%s", Param, err.Path, err.Reason)
+}
+
+// Mask 为解析、校验后的 update_mask
+type Mask struct {
+	paths []string
+}
+
+// Parse 解析 update_mask，并按资源 resource（结构体或其指针）的 json tag 校验字段路径：
+// resource 中不存在的字段为未知字段；resource 中存在、但更新请求参数 writable 中不存在的字段为只读字段（如：id、create_time）。
+//
+// text 为空或为 `*` 时，返回包含 resource、writable 共有的全部字段的 Mask，即：完整替换。
+// update_mask 有误时返回 *Error
+func Parse(text string, resource, writable interface{}) (*Mask, error) {
+	resourceType, writableType := structType(resource), structType(writable)
+	text = strings.TrimSpace(text)
+	if text == "" || text == "*" {
+		paths := make([]string, 0)
+		for _, name := range jsonNames(resourceType) {
+			if _, ok := lookup(writableType, name); ok {
+				paths = append(paths, name)
+			}
+		}
+		return &Mask{paths: paths}, nil
+	}
+
+	paths := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, path := range strings.Split(text, ",") {
+		path = strings.TrimSpace(path)
+		switch {
+		case path == "":
+			return nil, &Error{Path: path, Reason: "字段路径不能为空"}
+		case path == "*":
+			return nil, &Error{Path: path, Reason: "`*`不能与其它字段同时使用"}
+		case seen[path]:
+			return nil, &Error{Path: path, Reason: "重复的字段"}
+		}
+		if _, ok := lookup(resourceType, path); !ok {
+			return nil, &Error{Path: path, Reason: "未知的字段，可用字段：" + strings.Join(jsonNames(resourceType), ", ")}
+		}
+		if _, ok := lookup(writableType, path); !ok {
+			return nil, &Error{Path: path, Reason: "只读字段，不能更新"}
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+	return &Mask{paths: paths}, nil
+}
+
+// Paths 返回 update_mask 中的字段路径
+func (m *Mask) Paths() []string {
+	return append([]string(nil), m.paths...)
+}
+
+// Contains 判断字段路径 path 是否在 update_mask 中（含嵌套在 update_mask 中字段下的字段）
+func (m *Mask) Contains(path string) bool {
+	for _, p := range m.paths {
+		if path == p || strings.HasPrefix(path, p+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// structType 返回结构体或结构体指针的结构体类型
+func structType(v interface{}) reflect.Type {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// jsonName 返回结构体字段的 json 名称，与 ginvalidator 中字段错误信息的名称一致；不参与 json 编码的字段返回空字符串
+func jsonName(f reflect.StructField) string {
+	if f.PkgPath != "" {
+		return ""
+	}
+	name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// jsonNames 返回结构体中所有字段的 json 名称，按字母排序
+func jsonNames(t reflect.Type) []string {
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonName(t.Field(i)); name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// lookup 按 json 名称查找字段路径对应的结构体字段
+func lookup(t reflect.Type, path string) (reflect.StructField, bool) {
+	var f reflect.StructField
+	for _, name := range strings.Split(path, ".") {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return f, false
+		}
+		found := false
+		for i := 0; i < t.NumField(); i++ {
+			if jsonName(t.Field(i)) == name {
+				f, found = t.Field(i), true
+				break
+			}
+		}
+		if !found {
+			return f, false
+		}
+		t = f.Type
+	}
+	return f, true
+}
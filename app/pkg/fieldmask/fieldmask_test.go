@@ -0,0 +1,69 @@
+package fieldmask
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type testAddress struct {
+	City   string `json:"city"`
+	Street string `json:"street"`
+}
+
+type testResource struct {
+	Id         int64        `json:"id"`
+	Name       string       `json:"name"`
+	Address    *testAddress `json:"address"`
+	CreateTime time.Time    `json:"create_time"`
+	internal   string
+}
+
+type testForm struct {
+	Name       string       `json:"name"`
+	Address    *testAddress `json:"address"`
+	UpdateMask string       `json:"update_mask"`
+}
+
+func TestParse(t *testing.T) {
+	a := assert.New(t)
+	mask, err := Parse("", &testResource{}, &testForm{})
+	a.Nil(err)
+	a.Equal([]string{"address", "name"}, mask.Paths())
+	mask, err = Parse("*", testResource{}, testForm{})
+	a.Nil(err)
+	a.Equal([]string{"address", "name"}, mask.Paths())
+
+	mask, err = Parse(" name , address.city", &testResource{}, &testForm{})
+	a.Nil(err)
+	a.Equal([]string{"name", "address.city"}, mask.Paths())
+	a.True(mask.Contains("name"))
+	a.True(mask.Contains("address.city"))
+	a.False(mask.Contains("address"))
+	a.False(mask.Contains("address.street"))
+	mask, err = Parse("address", &testResource{}, &testForm{})
+	a.Nil(err)
+	a.True(mask.Contains("address.street"))
+
+	tests := []struct {
+		text   string
+		path   string
+		reason string
+	}{
+		{"name,", "", "字段路径不能为空"},
+		{"name,*", "*", "`*`不能与其它字段同时使用"},
+		{"name,name", "name", "重复的字段"},
+		{"phone", "phone", "未知的字段，可用字段：address, create_time, id, name"},
+		{"internal", "internal", "未知的字段，可用字段：address, create_time, id, name"},
+		{"address.zip", "address.zip", "未知的字段，可用字段：address, create_time, id, name"},
+		{"name.first", "name.first", "未知的字段，可用字段：address, create_time, id, name"},
+		{"update_mask", "update_mask", "未知的字段，可用字段：address, create_time, id, name"},
+		{"create_time", "create_time", "只读字段，不能更新"},
+	}
+	for _, test := range tests {
+		_, err := Parse(test.text, &testResource{}, &testForm{})
+		a.Equal(&Error{Path: test.path, Reason: test.reason}, err, test.text)
+	}
+	_, err = Parse("id", &testResource{}, &testForm{})
+	a.Equal("update_mask中的`id`：只读字段，不能更新", err.Error())
+}
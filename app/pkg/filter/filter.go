@@ -0,0 +1,284 @@
+package filter
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// MaxLength 为 filter、order_by 的最大长度（字符数）
+	MaxLength = 1024
+	// maxDepth 为 filter 中括号、NOT 的最大嵌套层数
+	maxDepth = 32
+)
+
+// Filter 为解析后的 filter 表达式
+type Filter struct {
+	root node
+}
+
+// ParseFilter 按字段白名单解析 filter 表达式，表达式为空时返回 nil；表达式有误时返回 *Error
+func (s *Schema) ParseFilter(text string) (*Filter, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	if utf8.RuneCountInString(text) > MaxLength {
+		return nil, &Error{Param: ParamFilter, Pos: MaxLength + 1, Reason: "长度不能超过" + strconv.Itoa(MaxLength) + "个字符"}
+	}
+	tokens, err := lex(ParamFilter, text)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{schema: s, tokens: tokens}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		if tok.kind == tokenRParen {
+			return nil, p.error(tok, "多余的 )")
+		}
+		return nil, p.error(tok, "多余的内容，多个条件请使用 AND、OR 连接")
+	}
+	return &Filter{root: root}, nil
+}
+
+// SQL 返回 filter 对应的 SQL 条件（可直接用于 WHERE）及其参数，f 为 nil 时返回空字符串
+func (f *Filter) SQL() (string, []interface{}) {
+	if f == nil {
+		return "", nil
+	}
+	var (
+		sb   strings.Builder
+		args = make([]interface{}, 0)
+	)
+	f.root.sql(&sb, &args)
+	return sb.String(), args
+}
+
+// node 为 filter 语法树的节点
+type node interface {
+	sql(sb *strings.Builder, args *[]interface{})
+}
+
+// logical 为 AND、OR 连接的条件
+type logical struct {
+	op    string
+	nodes []node
+}
+
+func (n *logical) sql(sb *strings.Builder, args *[]interface{}) {
+	sb.WriteString("(")
+	for i, child := range n.nodes {
+		if i > 0 {
+			sb.WriteString(" " + n.op + " ")
+		}
+		child.sql(sb, args)
+	}
+	sb.WriteString(")")
+}
+
+// not 为 NOT 条件
+type not struct {
+	node node
+}
+
+func (n *not) sql(sb *strings.Builder, args *[]interface{}) {
+	sb.WriteString("NOT (")
+	n.node.sql(sb, args)
+	sb.WriteString(")")
+}
+
+// comparison 为字段与值的比较
+type comparison struct {
+	field Field
+	op    string
+	// 转换为字段类型后的值；op 为 `:` 且值为 `*` 时为 nil
+	value interface{}
+}
+
+func (n *comparison) sql(sb *strings.Builder, args *[]interface{}) {
+	switch {
+	case n.op == ":" && n.value == nil:
+		if n.field.Type == TypeString {
+			sb.WriteString(n.field.Column + " <> ''")
+		} else {
+			sb.WriteString(n.field.Column + " IS NOT NULL")
+		}
+	case n.op == ":":
+		sb.WriteString(n.field.Column + ` LIKE ? ESCAPE '\'`)
+		*args = append(*args, "%"+likeEscaper.Replace(n.value.(string))+"%")
+	default:
+		sb.WriteString(n.field.Column + " " + sqlOperators[n.op] + " ?")
+		*args = append(*args, n.value)
+	}
+}
+
+// likeEscaper 转义 LIKE 的通配符
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// sqlOperators 为比较运算符对应的 SQL 运算符
+var sqlOperators = map[string]string{"=": "=", "!=": "<>", "<": "<", "<=": "<=", ">": ">", ">=": ">="}
+
+// parser 为 filter 的递归下降解析器
+type parser struct {
+	schema *Schema
+	tokens []token
+	i      int
+	depth  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.i]
+	if tok.kind != tokenEOF {
+		p.i++
+	}
+	return tok
+}
+
+func (p *parser) error(tok token, reason string) *Error {
+	return &Error{Param: ParamFilter, Pos: tok.pos, Token: tok.raw, Reason: reason}
+}
+
+// parseExpr 解析 term { "AND" term }
+func (p *parser) parseExpr() (node, error) {
+	return p.parseLogical("AND", p.parseTerm)
+}
+
+// parseTerm 解析 factor { "OR" factor }
+func (p *parser) parseTerm() (node, error) {
+	return p.parseLogical("OR", p.parseFactor)
+}
+
+func (p *parser) parseLogical(op string, parseOperand func() (node, error)) (node, error) {
+	first, err := parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []node{first}
+	for p.peek().keyword(op) {
+		p.next()
+		operand, err := parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, operand)
+	}
+	if len(nodes) == 1 {
+		return first, nil
+	}
+	return &logical{op: op, nodes: nodes}, nil
+}
+
+// parseFactor 解析 [ "NOT" ] ( "(" expr ")" | comparison )
+func (p *parser) parseFactor() (node, error) {
+	tok := p.peek()
+	if tok.keyword("NOT") || tok.kind == tokenLParen {
+		if p.depth++; p.depth > maxDepth {
+			return nil, p.error(tok, "嵌套层数不能超过"+strconv.Itoa(maxDepth))
+		}
+		defer func() { p.depth-- }()
+	}
+	if tok.keyword("NOT") {
+		p.next()
+		operand, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return &not{node: operand}, nil
+	}
+	if tok.kind == tokenLParen {
+		p.next()
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, p.error(closing, "缺少与第"+strconv.Itoa(tok.pos)+"个字符处的 ( 匹配的 )")
+		}
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+// parseComparison 解析 field operator value
+func (p *parser) parseComparison() (node, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == tokenEOF:
+		return nil, p.error(tok, "缺少条件")
+	case tok.kind != tokenText || tok.keyword("AND") || tok.keyword("OR"):
+		return nil, p.error(tok, "应为字段名称")
+	}
+	field, err := p.schema.field(ParamFilter, tok)
+	if err != nil {
+		return nil, err
+	}
+
+	opTok := p.next()
+	if opTok.kind != tokenOperator {
+		return nil, p.error(opTok, "应为比较运算符：= != < <= > >= :")
+	}
+	op := opTok.text
+
+	valueTok := p.next()
+	if valueTok.kind != tokenString && (valueTok.kind != tokenText || valueTok.keyword("AND") || valueTok.keyword("OR") || valueTok.keyword("NOT")) {
+		return nil, p.error(valueTok, "缺少`"+field.Name+" "+op+"`比较的值")
+	}
+	if op == ":" && valueTok.kind == tokenText && valueTok.text == "*" {
+		return &comparison{field: field, op: op}, nil
+	}
+	if !supportsOperator(field.Type, op) {
+		return nil, p.error(opTok, "字段`"+field.Name+"`不支持运算符"+op)
+	}
+	value, reason := convertValue(field.Type, valueTok.text)
+	if reason != "" {
+		return nil, p.error(valueTok, reason)
+	}
+	return &comparison{field: field, op: op, value: value}, nil
+}
+
+// supportsOperator 判断字段类型是否支持比较运算符（`:*` 除外）
+func supportsOperator(t Type, op string) bool {
+	switch t {
+	case TypeString:
+		return true
+	case TypeBool:
+		return op == "=" || op == "!="
+	default:
+		return op != ":"
+	}
+}
+
+// convertValue 将值转换为字段类型，失败时返回原因
+func convertValue(t Type, text string) (interface{}, string) {
+	switch t {
+	case TypeInt:
+		v, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, "不是有效的整数"
+		}
+		return v, ""
+	case TypeBool:
+		v, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, "不是有效的布尔值，请使用 true、false"
+		}
+		return v, ""
+	case TypeTimestamp:
+		v, err := time.Parse(time.RFC3339Nano, text)
+		if err != nil {
+			return nil, `不是有效的时间，请使用带引号的 RFC 3339 格式，例："2021-01-01T00:00:00Z"`
+		}
+		// 数据库中的时间均为 UTC
+		return v.UTC(), ""
+	default:
+		return text, ""
+	}
+}
@@ -0,0 +1,88 @@
+package filter
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSchema = NewSchema("id",
+	Field{Name: "id", Column: "id", Type: TypeInt, Sortable: true},
+	Field{Name: "name", Column: "user_name", Type: TypeString, Sortable: true},
+	Field{Name: "active", Column: "active", Type: TypeBool},
+	Field{Name: "create_time", Column: "create_time", Type: TypeTimestamp, Sortable: true},
+)
+
+func TestParseFilter(t *testing.T) {
+	a := assert.New(t)
+	tests := []struct {
+		filter string
+		sql    string
+		args   []interface{}
+	}{
+		{`id = 1`, `id = ?`, []interface{}{int64(1)}},
+		{`name != "a b" AND id >= -2`, `(user_name <> ? AND id >= ?)`, []interface{}{"a b", int64(-2)}},
+		// OR 的优先级高于 AND
+		{`id < 1 AND id > 2 OR active = true`, `(id < ? AND (id > ? OR active = ?))`, []interface{}{int64(1), int64(2), true}},
+		{`NOT (id = 1 OR id = 2)`, `NOT ((id = ? OR id = ?))`, []interface{}{int64(1), int64(2)}},
+		{`name:'50%_a\'b'`, `user_name LIKE ? ESCAPE '\'`, []interface{}{`%50\%\_a'b%`}},
+		{`name:* AND create_time:*`, `(user_name <> '' AND create_time IS NOT NULL)`, []interface{}{}},
+		{`create_time <= "2021-01-01T08:00:00+08:00"`, `create_time <= ?`, []interface{}{time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}},
+	}
+	for _, test := range tests {
+		f, err := testSchema.ParseFilter(test.filter)
+		if !a.Nil(err, test.filter) {
+			continue
+		}
+		sql, args := f.SQL()
+		a.Equal(test.sql, sql, test.filter)
+		a.Equal(test.args, args, test.filter)
+	}
+
+	f, err := testSchema.ParseFilter("  ")
+	a.Nil(err)
+	sql, args := f.SQL()
+	a.Equal("", sql)
+	a.Nil(args)
+}
+
+func TestParseFilterError(t *testing.T) {
+	a := assert.New(t)
+	tests := []struct {
+		filter string
+		pos    int
+		token  string
+	}{
+		{`phone = 1`, 1, "phone"},
+		{`id = 1 AND`, 11, ""},
+		{`id = 1 name = "a"`, 8, "name"},
+		{`id 1`, 4, "1"},
+		{`id = abc`, 6, "abc"},
+		{`id : 1`, 4, ":"},
+		{`active > true`, 8, ">"},
+		{`create_time > 2021-01-01T00:00:00Z`, 15, "2021-01-01T00"},
+		{`(id = 1`, 8, ""},
+		{`id = 1)`, 7, ")"},
+		{`name = "abc`, 8, `"abc`},
+		{`id ! 1`, 4, "!"},
+		{`名字 = 1`, 1, "名字"},
+		{`id = 1 AND 名字 = 1`, 12, "名字"},
+		{strings.Repeat("NOT ", maxDepth+1) + "id = 1", 4*maxDepth + 1, "NOT"},
+	}
+	for _, test := range tests {
+		_, err := testSchema.ParseFilter(test.filter)
+		e, ok := err.(*Error)
+		if !a.True(ok, test.filter) {
+			continue
+		}
+		a.Equal(ParamFilter, e.Param, test.filter)
+		a.Equal(test.pos, e.Pos, test.filter)
+		a.Equal(test.token, e.Token, test.filter)
+	}
+
+	_, err := testSchema.ParseFilter("phone = 1")
+	a.Equal("filter第1个字符处的`phone`：未知的字段，可用字段：active, create_time, id, name", err.Error())
+	_, err = testSchema.ParseFilter(strings.Repeat("a", MaxLength+1))
+	a.Equal(MaxLength+1, err.(*Error).Pos)
+}
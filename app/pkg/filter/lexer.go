@@ -0,0 +1,102 @@
+package filter
+
+import (
+	"strings"
+	"unicode"
+)
+
+// tokenKind 为词法单元类型
+type tokenKind int
+
+const (
+	tokenEOF      tokenKind = iota
+	tokenText               // 字段名称、不带引号的值、关键字
+	tokenString             // 带引号的字符串
+	tokenOperator           // = != < <= > >= :
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+// token 为词法单元
+type token struct {
+	kind tokenKind
+	// 文本，带引号的字符串为去除引号、转义后的内容
+	text string
+	// 原文
+	raw string
+	// 位置：第几个字符，从 1 开始
+	pos int
+}
+
+// keyword 判断 token 是否为关键字（AND、OR、NOT，须大写）
+func (tok token) keyword(word string) bool {
+	return tok.kind == tokenText && tok.text == word
+}
+
+// lex 将表达式拆分为词法单元，以 tokenEOF 结尾；存在未闭合的引号、非法字符时返回 *Error
+func lex(param, text string) ([]token, error) {
+	runes := []rune(text)
+	tokens := make([]token, 0)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", raw: "(", pos: start + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", raw: ")", pos: start + 1})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", raw: ",", pos: start + 1})
+			i++
+		case r == '=' || r == ':':
+			tokens = append(tokens, token{kind: tokenOperator, text: string(r), raw: string(r), pos: start + 1})
+			i++
+		case r == '<' || r == '>' || r == '!':
+			op := string(r)
+			i++
+			if i < len(runes) && runes[i] == '=' {
+				op += "="
+				i++
+			}
+			if op == "!" {
+				return nil, &Error{Param: param, Pos: start + 1, Token: op, Reason: "非法的运算符，不等于请使用 !="}
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, raw: op, pos: start + 1})
+		case r == '"' || r == '\'':
+			var sb strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				c := runes[i]
+				if c == '\\' && i+1 < len(runes) {
+					sb.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				i++
+				if c == r {
+					closed = true
+					break
+				}
+				sb.WriteRune(c)
+			}
+			if !closed {
+				return nil, &Error{Param: param, Pos: start + 1, Token: string(runes[start:]), Reason: "引号未闭合"}
+			}
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), raw: string(runes[start:i]), pos: start + 1})
+		default:
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(`()=:<>!,"'`, runes[i]) {
+				i++
+			}
+			word := string(runes[start:i])
+			tokens = append(tokens, token{kind: tokenText, text: word, raw: word, pos: start + 1})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes) + 1}), nil
+}
@@ -0,0 +1,192 @@
+package filter
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Order 为一个排序字段
+type Order struct {
+	Field Field
+	Desc  bool
+}
+
+// OrderBy 为解析后的 order_by，最后一个字段总是 Schema 的唯一键字段
+type OrderBy []Order
+
+// ParseOrderBy 按字段白名单解析 order_by，为空时按唯一键字段升序排列；有误时返回 *Error
+func (s *Schema) ParseOrderBy(text string) (OrderBy, error) {
+	if utf8.RuneCountInString(text) > MaxLength {
+		return nil, &Error{Param: ParamOrderBy, Pos: MaxLength + 1, Reason: "长度不能超过" + strconv.Itoa(MaxLength) + "个字符"}
+	}
+	tokens, err := lex(ParamOrderBy, text)
+	if err != nil {
+		return nil, err
+	}
+	orderBy := make(OrderBy, 0)
+	seen := make(map[string]bool)
+	for i := 0; tokens[i].kind != tokenEOF; {
+		tok := tokens[i]
+		if tok.kind != tokenText {
+			return nil, &Error{Param: ParamOrderBy, Pos: tok.pos, Token: tok.raw, Reason: "应为字段名称"}
+		}
+		field, err := s.field(ParamOrderBy, tok)
+		if err != nil {
+			return nil, err
+		}
+		if !field.Sortable {
+			return nil, &Error{Param: ParamOrderBy, Pos: tok.pos, Token: tok.raw, Reason: "不支持排序的字段，可排序字段：" + strings.Join(s.sortable(), ", ")}
+		}
+		if seen[field.Name] {
+			return nil, &Error{Param: ParamOrderBy, Pos: tok.pos, Token: tok.raw, Reason: "重复的排序字段"}
+		}
+		seen[field.Name] = true
+		order := Order{Field: field}
+		i++
+
+		if tok := tokens[i]; tok.kind == tokenText {
+			switch strings.ToLower(tok.text) {
+			case "asc":
+			case "desc":
+				order.Desc = true
+			default:
+				return nil, &Error{Param: ParamOrderBy, Pos: tok.pos, Token: tok.raw, Reason: "应为排序方向：asc、desc，多个字段请使用 , 分隔"}
+			}
+			i++
+		}
+		orderBy = append(orderBy, order)
+
+		switch tok := tokens[i]; tok.kind {
+		case tokenEOF:
+		case tokenComma:
+			i++
+			if next := tokens[i]; next.kind == tokenEOF {
+				return nil, &Error{Param: ParamOrderBy, Pos: next.pos, Reason: "逗号后缺少字段名称"}
+			}
+		default:
+			return nil, &Error{Param: ParamOrderBy, Pos: tok.pos, Token: tok.raw, Reason: "多个字段请使用 , 分隔"}
+		}
+	}
+	if !seen[s.key.Name] {
+		orderBy = append(orderBy, Order{Field: s.key})
+	}
+	return orderBy, nil
+}
+
+// sortable 返回可排序字段的名称
+func (s *Schema) sortable() []string {
+	names := make([]string, 0)
+	for _, name := range s.names {
+		if s.fields[name].Sortable {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// SQL 返回 ORDER BY 子句（不含 ORDER BY），例：`create_time DESC, id ASC`
+func (orderBy OrderBy) SQL() string {
+	columns := make([]string, len(orderBy))
+	for i, order := range orderBy {
+		if order.Desc {
+			columns[i] = order.Field.Column + " DESC"
+		} else {
+			columns[i] = order.Field.Column + " ASC"
+		}
+	}
+	return strings.Join(columns, ", ")
+}
+
+// After 返回排在排序键 keys（由 DecodeKeys 返回）对应的记录之后的 SQL 条件及其参数，用于游标分页，
+// 例：`create_time DESC, id ASC` -> `(create_time < ? OR (create_time = ? AND id > ?))`
+func (orderBy OrderBy) After(keys []interface{}) (string, []interface{}) {
+	conditions := make([]string, len(orderBy))
+	args := make([]interface{}, 0)
+	for i, order := range orderBy {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, orderBy[j].Field.Column+" = ?")
+			args = append(args, keys[j])
+		}
+		if order.Desc {
+			parts = append(parts, order.Field.Column+" < ?")
+		} else {
+			parts = append(parts, order.Field.Column+" > ?")
+		}
+		args = append(args, keys[i])
+		if len(parts) == 1 {
+			conditions[i] = parts[0]
+		} else {
+			conditions[i] = "(" + strings.Join(parts, " AND ") + ")"
+		}
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// EncodeKeys 返回记录 row（结构体或其指针）json 编码后的排序键，用于生成分页游标；
+// 按 json tag 查找与字段名称相同的结构体字段
+func (orderBy OrderBy) EncodeKeys(row interface{}) []json.RawMessage {
+	v := reflect.Indirect(reflect.ValueOf(row))
+	keys := make([]json.RawMessage, len(orderBy))
+	for i, order := range orderBy {
+		data, err := json.Marshal(fieldByJsonName(v, order.Field.Name).Interface())
+		if err != nil {
+			panic(err)
+		}
+		keys[i] = data
+	}
+	return keys
+}
+
+// DecodeKeys 按字段类型解码 EncodeKeys 返回的排序键，数量或类型不符时返回 false
+func (orderBy OrderBy) DecodeKeys(raw []json.RawMessage) ([]interface{}, bool) {
+	if len(raw) != len(orderBy) {
+		return nil, false
+	}
+	keys := make([]interface{}, len(orderBy))
+	for i, order := range orderBy {
+		var (
+			value interface{}
+			err   error
+		)
+		switch order.Field.Type {
+		case TypeString:
+			var v string
+			err = json.Unmarshal(raw[i], &v)
+			value = v
+		case TypeInt:
+			var v int64
+			err = json.Unmarshal(raw[i], &v)
+			value = v
+		case TypeBool:
+			var v bool
+			err = json.Unmarshal(raw[i], &v)
+			value = v
+		case TypeTimestamp:
+			var v time.Time
+			err = json.Unmarshal(raw[i], &v)
+			value = v.UTC()
+		}
+		if err != nil {
+			return nil, false
+		}
+		keys[i] = value
+	}
+	return keys, true
+}
+
+// fieldByJsonName 按 json tag 查找结构体字段，不存在时 panic（Schema 与模型不一致）
+func fieldByJsonName(v reflect.Value, name string) reflect.Value {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if tag == name {
+			return v.Field(i)
+		}
+	}
+	panic("filter: field `" + name + "` not found in " + t.String())
+}
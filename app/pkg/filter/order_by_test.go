@@ -0,0 +1,76 @@
+package filter
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestParseOrderBy(t *testing.T) {
+	a := assert.New(t)
+	orderBy, err := testSchema.ParseOrderBy("")
+	a.Nil(err)
+	a.Equal("id ASC", orderBy.SQL())
+	orderBy, err = testSchema.ParseOrderBy("create_time desc, name")
+	a.Nil(err)
+	a.Equal("create_time DESC, user_name ASC, id ASC", orderBy.SQL())
+	orderBy, err = testSchema.ParseOrderBy("id DESC, name")
+	a.Nil(err)
+	a.Equal("id DESC, user_name ASC", orderBy.SQL())
+
+	tests := []struct {
+		orderBy string
+		pos     int
+		token   string
+	}{
+		{"phone", 1, "phone"},
+		{"active", 1, "active"},
+		{"name, name", 7, "name"},
+		{"name up", 6, "up"},
+		{"name id", 6, "id"},
+		{"name desc id", 11, "id"},
+		{"name,", 6, ""},
+		{", name", 1, ","},
+	}
+	for _, test := range tests {
+		_, err := testSchema.ParseOrderBy(test.orderBy)
+		e, ok := err.(*Error)
+		if !a.True(ok, test.orderBy) {
+			continue
+		}
+		a.Equal(ParamOrderBy, e.Param, test.orderBy)
+		a.Equal(test.pos, e.Pos, test.orderBy)
+		a.Equal(test.token, e.Token, test.orderBy)
+	}
+}
+
+func TestOrderByKeys(t *testing.T) {
+	a := assert.New(t)
+	orderBy, err := testSchema.ParseOrderBy("create_time desc, name")
+	a.Nil(err)
+
+	createTime := time.Date(2021, 1, 1, 8, 0, 0, 1, time.FixedZone("CST", 8*3600))
+	row := &struct {
+		Id         int64     `json:"id"`
+		Name       string    `json:"name,omitempty"`
+		CreateTime time.Time `json:"create_time"`
+	}{Id: 3, Name: "alice", CreateTime: createTime}
+	raw := orderBy.EncodeKeys(row)
+	// 模拟游标的 json 编码、解码
+	data, err := json.Marshal(raw)
+	a.Nil(err)
+	a.Nil(json.Unmarshal(data, &raw))
+	keys, ok := orderBy.DecodeKeys(raw)
+	a.True(ok)
+	a.Equal([]interface{}{createTime.UTC(), "alice", int64(3)}, keys)
+
+	sql, args := orderBy.After(keys)
+	a.Equal("(create_time < ? OR (create_time = ? AND user_name > ?) OR (create_time = ? AND user_name = ? AND id > ?))", sql)
+	a.Equal([]interface{}{createTime.UTC(), createTime.UTC(), "alice", createTime.UTC(), "alice", int64(3)}, args)
+
+	_, ok = orderBy.DecodeKeys(raw[:2])
+	a.False(ok)
+	_, ok = orderBy.DecodeKeys([]json.RawMessage{raw[1], raw[1], raw[2]})
+	a.False(ok)
+}
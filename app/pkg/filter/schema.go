@@ -0,0 +1,107 @@
+// 本包用于列表接口的 filter、order_by 参数（谷歌 API 设计指南，see: https://google.aip.dev/160、https://google.aip.dev/132#ordering）：
+// 按资源的字段白名单（Schema）解析表达式，并转换为参数化的 SQL 条件、排序子句，供 dao 层使用。
+//
+// filter 语法：
+//
+//	expr       = term { "AND" term }
+//	term       = factor { "OR" factor }
+//	factor     = [ "NOT" ] ( "(" expr ")" | comparison )
+//	comparison = field ( "=" | "!=" | "<" | "<=" | ">" | ">=" | ":" ) value
+//
+// 注意：与谷歌 API 设计指南一致，OR 的优先级高于 AND，例：`a = 1 AND b = 2 OR c = 3` 等价于 `a = 1 AND (b = 2 OR c = 3)`。
+// value 可以是带引号的字符串（"..." 或 '...'）或不含空白、运算符的文本；时间使用 RFC 3339 格式，例："2021-01-01T00:00:00Z"。
+// `field:value` 表示字符串字段包含 value，`field:*` 表示字段非空。
+//
+// order_by 语法：以 `,` 分隔的字段，字段后可加 asc（默认）、desc，例：`create_time desc, username`。
+
+package filter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Type 为字段类型
+type Type int
+
+const (
+	TypeString Type = iota
+	TypeInt
+	TypeBool
+	// 时间，请求中使用 RFC 3339 格式，转换为 UTC 的 time.Time
+	TypeTimestamp
+)
+
+// Field 为允许在 filter、order_by 中使用的字段
+type Field struct {
+	// 请求中使用的字段名称，与资源的 json 字段名称一致，例：create_time
+	Name string
+	// 数据库列名
+	Column string
+	Type   Type
+	// 是否允许在 order_by 中使用
+	Sortable bool
+}
+
+// Schema 为资源允许在 filter、order_by 中使用的字段白名单
+type Schema struct {
+	fields map[string]Field
+	names  []string
+	// 唯一键字段，总是作为最后一个排序字段，以保证排序稳定（游标分页依赖于此）
+	key Field
+}
+
+// NewSchema 实例化资源的字段白名单，key 为唯一键字段（如：id），须包含在 fields 中
+func NewSchema(key string, fields ...Field) *Schema {
+	schema := &Schema{fields: make(map[string]Field, len(fields))}
+	for _, field := range fields {
+		schema.fields[field.Name] = field
+		schema.names = append(schema.names, field.Name)
+	}
+	sort.Strings(schema.names)
+	var ok bool
+	if schema.key, ok = schema.fields[key]; !ok {
+		panic(fmt.Sprintf("filter: key field `%s` is not in fields", key))
+	}
+	return schema
+}
+
+// 请求参数名称
+const (
+	ParamFilter  = "filter"
+	ParamOrderBy = "order_by"
+)
+
+// Error 为 filter、order_by 参数错误，应响应 e.CodeInvalidArgument 错误及 e.BadRequest 错误详情
+type Error struct {
+	// 错误的请求参数：filter、order_by
+	Param string
+	// 出错的位置：第几个字符，从 1 开始
+	Pos int
+	// 出错的文本，到达末尾时为空字符串
+	Token string
+	// 错误原因
+	Reason string
+}
+
+func (err *Error) Error() string {
+	if err.Token == "" {
+		return fmt.Sprintf("%s第%d个字符处：%s", err.Param, err.Pos, err.Reason)
+	}
+	return fmt.Sprintf("%s第%d个字符处的`%s`：%s", err.Param, err.Pos, err.Token, err.Reason)
+}
+
+// field 按名称查找字段，不存在时返回 *Error
+func (s *Schema) field(param string, tok token) (Field, error) {
+	field, ok := s.fields[tok.text]
+	if !ok {
+		return Field{}, &Error{
+			Param:  param,
+			Pos:    tok.pos,
+			Token:  tok.text,
+			Reason: "未知的字段，可用字段：" + strings.Join(s.names, ", "),
+		}
+	}
+	return field, nil
+}
@@ -0,0 +1,177 @@
+// 本包用于健康检查：各组件（缓存、短信服务、数据库等）向 Registry 注册检查函数，
+// Registry 并发执行所有检查（每项检查均有超时时间），汇总为存活（liveness）、就绪（readiness）状态。
+
+package health
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"project/app/pkg/config"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Config 为健康检查配置，对应配置文件中的 `health` 节点
+type Config struct {
+	// 单项检查的默认超时时间
+	Timeout time.Duration `mapstructure:"timeout" validate:"gt=0"`
+}
+
+// NewConfig 从 viper 中读取健康检查配置
+func NewConfig(v *viper.Viper) (*Config, error) {
+	cfg := &Config{Timeout: time.Second}
+	if err := config.Unmarshal(v, "health", cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// 检查状态
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+	// 服务正在优雅关闭
+	StatusShuttingDown = "shutting_down"
+)
+
+// CheckFunc 为检查函数，返回 nil 表示检查通过，须在 ctx 结束时尽快返回
+type CheckFunc func(ctx context.Context) error
+
+// Check 为一项健康检查
+type Check struct {
+	// 检查名称，例：cache、sms、database
+	Name string
+	// 检查函数
+	Func CheckFunc
+	// 超时时间，为 0 时使用 Config.Timeout
+	Timeout time.Duration
+	// 是否为可选检查：可选检查失败不影响就绪状态，仅在检查报告中体现（如：多实例共用的外部服务）
+	Optional bool
+}
+
+// Report 为健康检查报告
+type Report struct {
+	// 整体状态：up、down、shutting_down
+	Status string `json:"status"`
+	// 各项检查结果，按名称排序
+	Checks []CheckResult `json:"checks"`
+}
+
+// CheckResult 为单项检查结果
+type CheckResult struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Optional bool   `json:"optional"`
+	// 检查耗时，例：1.2ms
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Registry 为健康检查注册表
+type Registry struct {
+	cfg *Config
+
+	mu     sync.RWMutex
+	checks map[string]Check
+
+	shuttingDown int32
+}
+
+func NewRegistry(cfg *Config) *Registry {
+	return &Registry{cfg: cfg, checks: map[string]Check{}}
+}
+
+// Register 注册一项健康检查，同名检查将被覆盖
+func (r *Registry) Register(check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[check.Name] = check
+}
+
+// SetShuttingDown 标记服务正在优雅关闭，此后就绪状态恒为 false
+func (r *Registry) SetShuttingDown() {
+	atomic.StoreInt32(&r.shuttingDown, 1)
+}
+
+// IsShuttingDown 判断服务是否正在优雅关闭
+func (r *Registry) IsShuttingDown() bool {
+	return atomic.LoadInt32(&r.shuttingDown) == 1
+}
+
+// Check 并发执行所有检查，返回检查报告及是否就绪：
+// 服务正在优雅关闭，或任一非可选检查失败时，未就绪。
+func (r *Registry) Check(ctx context.Context) (Report, bool) {
+	r.mu.RLock()
+	checks := make([]Check, 0, len(r.checks))
+	for _, check := range r.checks {
+		checks = append(checks, check)
+	}
+	r.mu.RUnlock()
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].Name < checks[j].Name
+	})
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = r.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: results}
+	for _, result := range results {
+		if result.Status != StatusUp && !result.Optional {
+			report.Status = StatusDown
+		}
+	}
+	if r.IsShuttingDown() {
+		report.Status = StatusShuttingDown
+	}
+	return report, report.Status == StatusUp
+}
+
+// run 执行单项检查。检查函数未在超时时间内返回时，视为检查失败，不等待其返回。
+func (r *Registry) run(ctx context.Context, check Check) CheckResult {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = r.cfg.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				errCh <- errors.Errorf("health check panic: %v", recovered)
+			}
+		}()
+		errCh <- check.Func(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = errors.Errorf("health check timeout after %s", timeout)
+	}
+	result := CheckResult{
+		Name:     check.Name,
+		Status:   StatusUp,
+		Optional: check.Optional,
+		Duration: time.Since(start).String(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
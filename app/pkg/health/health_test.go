@@ -0,0 +1,63 @@
+package health
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewConfig(t *testing.T) {
+	a := assert.New(t)
+	cfg, err := NewConfig(viper.New())
+	a.Nil(err)
+	a.Equal(time.Second, cfg.Timeout)
+
+	v := viper.New()
+	v.Set("health.timeout", "0s")
+	_, err = NewConfig(v)
+	a.NotNil(err)
+}
+
+func TestRegistry_Check(t *testing.T) {
+	a := assert.New(t)
+	registry := NewRegistry(&Config{Timeout: 50 * time.Millisecond})
+
+	report, ready := registry.Check(context.Background())
+	a.True(ready)
+	a.Equal(StatusUp, report.Status)
+	a.Empty(report.Checks)
+
+	registry.Register(Check{Name: "b", Func: func(ctx context.Context) error { return nil }})
+	registry.Register(Check{Name: "a", Func: func(ctx context.Context) error { return errors.New("a failed") }, Optional: true})
+	report, ready = registry.Check(context.Background())
+	a.True(ready)
+	a.Equal(StatusUp, report.Status)
+	if a.Len(report.Checks, 2) {
+		a.Equal("a", report.Checks[0].Name)
+		a.Equal(StatusDown, report.Checks[0].Status)
+		a.Equal("a failed", report.Checks[0].Error)
+		a.Equal(StatusUp, report.Checks[1].Status)
+	}
+
+	// 超时、panic 均视为检查失败
+	registry.Register(Check{Name: "slow", Func: func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}})
+	registry.Register(Check{Name: "panic", Func: func(ctx context.Context) error { panic("boom") }, Timeout: time.Second})
+	start := time.Now()
+	report, ready = registry.Check(context.Background())
+	a.False(ready)
+	a.Equal(StatusDown, report.Status)
+	a.True(time.Since(start) < 500*time.Millisecond)
+	a.Contains(report.Checks[2].Error, "boom")
+	a.Contains(report.Checks[3].Error, "timeout")
+
+	registry.SetShuttingDown()
+	report, ready = registry.Check(context.Background())
+	a.False(ready)
+	a.Equal(StatusShuttingDown, report.Status)
+}
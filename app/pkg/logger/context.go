@@ -0,0 +1,16 @@
+package logger
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"project/app/pkg/requestid"
+)
+
+// WithContext 返回附加了 ctx 中请求 ID（request_id 字段）的 logger，
+// 使 handler 之外（如 service 层）记录的日志也能与 http 日志关联。
+func WithContext(ctx context.Context, zapLogger *zap.Logger) *zap.Logger {
+	if id := requestid.FromContext(ctx); id != "" {
+		return zapLogger.With(zap.String("request_id", id))
+	}
+	return zapLogger
+}
@@ -0,0 +1,240 @@
+package logger
+
+import (
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LevelController 负责在运行时调整日志级别。
+//
+// 支持：
+//   - 调整全局日志级别（即 NewZapLogger 使用的 zap.AtomicLevel）
+//   - 按 logger 名称（zap.Logger.Named()）覆盖日志级别，名称以 `.` 分隔层级，
+//     覆盖 `sms` 同时作用于 `sms.aliyun`，最长匹配优先
+//   - 限时调整：到期后自动恢复为调整前的级别
+type LevelController struct {
+	atomicLevel zap.AtomicLevel
+
+	mu      sync.RWMutex
+	base    *levelState            // 全局日志级别的限时调整状态，为 nil 表示未限时调整
+	loggers map[string]*levelState // 按 logger 名称覆盖的日志级别
+}
+
+// levelState 表示一次日志级别调整
+type levelState struct {
+	level    zapcore.Level
+	expireAt time.Time      // 到期时间，零值表示永久有效
+	previous *zapcore.Level // 到期后恢复的级别，为 nil 表示到期后移除该覆盖
+	timer    *time.Timer
+}
+
+// LevelStatus 描述当前生效的日志级别
+type LevelStatus struct {
+	Level    string     `json:"level"`
+	ExpireAt *time.Time `json:"expire_at,omitempty"`
+}
+
+// NewLevelController 实例化一个 *LevelController，初始全局日志级别取自日志配置
+func NewLevelController(cfg *Config) (*LevelController, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	return &LevelController{
+		atomicLevel: zap.NewAtomicLevelAt(level),
+		loggers:     map[string]*levelState{},
+	}, nil
+}
+
+// AtomicLevel 返回全局日志级别
+func (ctrl *LevelController) AtomicLevel() zap.AtomicLevel {
+	return ctrl.atomicLevel
+}
+
+// SetLevel 调整全局日志级别。duration 大于 0 时为限时调整，到期后恢复为调整前的级别。
+func (ctrl *LevelController) SetLevel(level zapcore.Level, duration time.Duration) {
+	ctrl.mu.Lock()
+	defer ctrl.mu.Unlock()
+
+	previous := ctrl.atomicLevel.Level()
+	if ctrl.base != nil {
+		// 限时调整尚未到期时再次调整，到期后仍应恢复为最初的级别
+		ctrl.base.timer.Stop()
+		previous = *ctrl.base.previous
+		ctrl.base = nil
+	}
+	ctrl.atomicLevel.SetLevel(level)
+	if duration <= 0 {
+		return
+	}
+
+	state := &levelState{level: level, expireAt: time.Now().Add(duration), previous: &previous}
+	state.timer = time.AfterFunc(duration, func() {
+		ctrl.mu.Lock()
+		defer ctrl.mu.Unlock()
+		if ctrl.base == state {
+			ctrl.atomicLevel.SetLevel(previous)
+			ctrl.base = nil
+		}
+	})
+	ctrl.base = state
+}
+
+// SetConfigLevel 将全局日志级别设置为配置的日志级别（配置热加载时调用），
+// 存在未到期的限时调整时不立即生效，到期后恢复为该级别。
+func (ctrl *LevelController) SetConfigLevel(level zapcore.Level) {
+	ctrl.mu.Lock()
+	defer ctrl.mu.Unlock()
+	if ctrl.base != nil {
+		*ctrl.base.previous = level
+		return
+	}
+	ctrl.atomicLevel.SetLevel(level)
+}
+
+// SetLoggerLevel 按 logger 名称覆盖日志级别。duration 大于 0 时为限时调整，到期后恢复为调整前的状态。
+func (ctrl *LevelController) SetLoggerLevel(name string, level zapcore.Level, duration time.Duration) {
+	ctrl.mu.Lock()
+	defer ctrl.mu.Unlock()
+
+	var previous *zapcore.Level
+	if state, ok := ctrl.loggers[name]; ok {
+		if state.timer != nil {
+			state.timer.Stop()
+			previous = state.previous
+		} else {
+			previousLevel := state.level
+			previous = &previousLevel
+		}
+	}
+	if duration <= 0 {
+		ctrl.loggers[name] = &levelState{level: level}
+		return
+	}
+
+	state := &levelState{level: level, expireAt: time.Now().Add(duration), previous: previous}
+	state.timer = time.AfterFunc(duration, func() {
+		ctrl.mu.Lock()
+		defer ctrl.mu.Unlock()
+		if ctrl.loggers[name] != state {
+			return
+		}
+		if previous != nil {
+			ctrl.loggers[name] = &levelState{level: *previous}
+		} else {
+			delete(ctrl.loggers, name)
+		}
+	})
+	ctrl.loggers[name] = state
+}
+
+// RemoveLoggerLevel 移除指定 logger 名称的日志级别覆盖，返回该覆盖是否存在
+func (ctrl *LevelController) RemoveLoggerLevel(name string) bool {
+	ctrl.mu.Lock()
+	defer ctrl.mu.Unlock()
+
+	state, ok := ctrl.loggers[name]
+	if !ok {
+		return false
+	}
+	if state.timer != nil {
+		state.timer.Stop()
+	}
+	delete(ctrl.loggers, name)
+	return true
+}
+
+// Status 返回当前生效的全局日志级别，以及所有按 logger 名称覆盖的日志级别
+func (ctrl *LevelController) Status() (base LevelStatus, loggers map[string]LevelStatus) {
+	ctrl.mu.RLock()
+	defer ctrl.mu.RUnlock()
+
+	base = LevelStatus{Level: ctrl.atomicLevel.Level().String()}
+	if ctrl.base != nil {
+		expireAt := ctrl.base.expireAt
+		base.ExpireAt = &expireAt
+	}
+	loggers = make(map[string]LevelStatus, len(ctrl.loggers))
+	for name, state := range ctrl.loggers {
+		status := LevelStatus{Level: state.level.String()}
+		if state.timer != nil {
+			expireAt := state.expireAt
+			status.ExpireAt = &expireAt
+		}
+		loggers[name] = status
+	}
+	return base, loggers
+}
+
+// Enabled 实现 zapcore.LevelEnabler：全局日志级别或任一 logger 覆盖级别允许记录即返回 true。
+//
+// 具体某条日志是否记录，由 loggerEnabled 按 logger 名称判断。
+func (ctrl *LevelController) Enabled(level zapcore.Level) bool {
+	if ctrl.atomicLevel.Enabled(level) {
+		return true
+	}
+	ctrl.mu.RLock()
+	defer ctrl.mu.RUnlock()
+	for _, state := range ctrl.loggers {
+		if state.level.Enabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+// loggerEnabled 判断名称为 name 的 logger 是否允许记录 level 级别的日志
+func (ctrl *LevelController) loggerEnabled(name string, level zapcore.Level) bool {
+	ctrl.mu.RLock()
+	defer ctrl.mu.RUnlock()
+	for len(ctrl.loggers) > 0 {
+		if state, ok := ctrl.loggers[name]; ok {
+			return state.level.Enabled(level)
+		}
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return ctrl.atomicLevel.Enabled(level)
+}
+
+// WrapCore 包装一个 zapcore.Core，使其按 logger 名称判断日志级别
+func (ctrl *LevelController) WrapCore(core zapcore.Core) zapcore.Core {
+	return &levelCore{Core: core, ctrl: ctrl}
+}
+
+// levelCore 包装一个 zapcore.Core，按 logger 名称判断日志级别
+type levelCore struct {
+	zapcore.Core
+	ctrl *LevelController
+}
+
+func (core *levelCore) Enabled(level zapcore.Level) bool {
+	return core.ctrl.Enabled(level)
+}
+
+func (core *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: core.Core.With(fields), ctrl: core.ctrl}
+}
+
+func (core *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !core.ctrl.loggerEnabled(entry.LoggerName, entry.Level) {
+		return checked
+	}
+	return core.Core.Check(entry, checked)
+}
+
+// ParseLevel 解析日志级别字符串，如：debug、info
+func ParseLevel(text string) (zapcore.Level, error) {
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(text)); err != nil {
+		return level, errors.Wrapf(err, "invalid log level `%s`", text)
+	}
+	return level, nil
+}
@@ -0,0 +1,89 @@
+package logger_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"project/app/pkg/logger"
+	"testing"
+	"time"
+)
+
+func TestLevelController_SetLevel(t *testing.T) {
+	a := assert.New(t)
+	ctrl, err := logger.NewLevelController(&logger.Config{Level: "info"})
+	a.Nil(err)
+
+	ctrl.SetLevel(zapcore.WarnLevel, 0)
+	ctrl.SetLevel(zapcore.DebugLevel, 50*time.Millisecond)
+	base, _ := ctrl.Status()
+	a.Equal("debug", base.Level)
+	a.NotNil(base.ExpireAt)
+
+	// 限时调整期间再次限时调整，到期后恢复为最初的级别
+	ctrl.SetLevel(zapcore.ErrorLevel, 50*time.Millisecond)
+	a.Eventually(func() bool {
+		return ctrl.AtomicLevel().Level() == zapcore.WarnLevel
+	}, time.Second, 10*time.Millisecond)
+	base, _ = ctrl.Status()
+	a.Nil(base.ExpireAt)
+}
+
+func TestLevelController_SetConfigLevel(t *testing.T) {
+	a := assert.New(t)
+	ctrl, err := logger.NewLevelController(&logger.Config{Level: "info"})
+	a.Nil(err)
+
+	ctrl.SetConfigLevel(zapcore.WarnLevel)
+	a.Equal(zapcore.WarnLevel, ctrl.AtomicLevel().Level())
+
+	// 限时调整期间不立即生效，到期后恢复为配置的级别
+	ctrl.SetLevel(zapcore.DebugLevel, 50*time.Millisecond)
+	ctrl.SetConfigLevel(zapcore.ErrorLevel)
+	a.Equal(zapcore.DebugLevel, ctrl.AtomicLevel().Level())
+	a.Eventually(func() bool {
+		return ctrl.AtomicLevel().Level() == zapcore.ErrorLevel
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestLevelController_SetLoggerLevel(t *testing.T) {
+	a := assert.New(t)
+	ctrl, err := logger.NewLevelController(&logger.Config{Level: "info"})
+	a.Nil(err)
+	core, logs := observer.New(ctrl)
+	zapLogger := zap.New(ctrl.WrapCore(core))
+
+	ctrl.SetLoggerLevel("sms", zapcore.DebugLevel, 0)
+	ctrl.SetLoggerLevel("sms.aliyun", zapcore.ErrorLevel, 50*time.Millisecond)
+	zapLogger.Debug("root")
+	zapLogger.Named("sms").Debug("sms")
+	zapLogger.Named("sms").Named("tencent").Debug("sms.tencent")
+	zapLogger.Named("sms").Named("aliyun").Warn("sms.aliyun")
+	a.Equal([]string{"sms", "sms.tencent"}, messages(logs))
+
+	_, loggers := ctrl.Status()
+	a.Len(loggers, 2)
+	a.NotNil(loggers["sms.aliyun"].ExpireAt)
+
+	// 到期后移除覆盖，继承 `sms` 的级别
+	a.Eventually(func() bool {
+		_, loggers := ctrl.Status()
+		return len(loggers) == 1
+	}, time.Second, 10*time.Millisecond)
+	zapLogger.Named("sms").Named("aliyun").Debug("sms.aliyun")
+	a.Equal("sms.aliyun", logs.All()[logs.Len()-1].Message)
+
+	a.True(ctrl.RemoveLoggerLevel("sms"))
+	a.False(ctrl.RemoveLoggerLevel("sms"))
+	zapLogger.Named("sms").Debug("removed")
+	a.Equal(3, logs.Len())
+}
+
+func messages(logs *observer.ObservedLogs) []string {
+	var messages []string
+	for _, entry := range logs.All() {
+		messages = append(messages, entry.Message)
+	}
+	return messages
+}
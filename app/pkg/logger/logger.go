@@ -0,0 +1,204 @@
+// 本包用于根据配置实例化 *zap.Logger
+
+package logger
+
+import (
+	"fmt"
+	"github.com/natefinch/lumberjack"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"os"
+	"project/app/pkg/config"
+	"time"
+)
+
+// Config 为日志配置，对应配置文件中的 `log` 节点
+type Config struct {
+	// 日志编码格式：json、console
+	Encoding string `mapstructure:"encoding" validate:"omitempty,oneof=json console"`
+	// 日志级别：debug、info、warn、error、dpanic、panic、fatal
+	Level string `mapstructure:"level" validate:"omitempty,oneof=debug info warn error dpanic panic fatal"`
+	// 日志输出路径，支持 stdout、stderr 及文件路径
+	OutputPaths []string `mapstructure:"outputPaths"`
+	// error 及以上级别日志的额外输出路径，为空则不单独输出
+	ErrorOutputPaths []string `mapstructure:"errorOutputPaths"`
+	// 文件日志切割配置，仅对文件路径生效
+	Rotate RotateConfig `mapstructure:"rotate"`
+	// 日志采样配置
+	Sampling SamplingConfig `mapstructure:"sampling"`
+	// 附加到每条日志的静态字段，如：服务名称、版本号
+	Fields map[string]string `mapstructure:"fields"`
+}
+
+// RotateConfig 为文件日志切割配置，see: lumberjack.Logger
+type RotateConfig struct {
+	// 单个日志文件的最大尺寸，单位：MB
+	MaxSize int `mapstructure:"maxSize" validate:"gte=0"`
+	// 旧日志文件的最长保留天数
+	MaxAge int `mapstructure:"maxAge" validate:"gte=0"`
+	// 旧日志文件的最大保留个数
+	MaxBackups int `mapstructure:"maxBackups" validate:"gte=0"`
+	// 是否使用本地时间命名旧日志文件
+	LocalTime bool `mapstructure:"localTime"`
+	// 是否使用 gzip 压缩旧日志文件
+	Compress bool `mapstructure:"compress"`
+}
+
+// SamplingConfig 为日志采样配置：每秒内相同级别、相同消息的日志，记录前 Initial 条，
+// 之后每 Thereafter 条记录一条。Initial 为 0 时不采样，Initial 大于 0 时 Thereafter 须大于 0
+// （zap 的采样器在 Thereafter 为 0 时丢弃前 Initial 条之后的所有日志）。
+type SamplingConfig struct {
+	Initial    int `mapstructure:"initial" validate:"gte=0"`
+	Thereafter int `mapstructure:"thereafter" validate:"required_with=Initial,gte=0"`
+}
+
+// NewConfig 从 viper 中读取日志配置，并根据是否处于开发者模式填充默认值
+func NewConfig(isDebug config.IsDebug, v *viper.Viper) (*Config, error) {
+	cfg := &Config{}
+	// 请求体/响应体采集配置、日志脱敏配置分别由 handler.NewBodyCaptureConfig、redact.NewRedactor 读取、校验
+	if err := config.Unmarshal(v, "log", cfg, config.IgnoreKeys("capture", "redact")); err != nil {
+		return nil, err
+	}
+	if cfg.Encoding == "" {
+		if isDebug {
+			cfg.Encoding = "console"
+		} else {
+			cfg.Encoding = "json"
+		}
+	}
+	if cfg.Level == "" {
+		if isDebug {
+			cfg.Level = zapcore.DebugLevel.String()
+		} else {
+			cfg.Level = zapcore.InfoLevel.String()
+		}
+	}
+	if len(cfg.OutputPaths) == 0 {
+		cfg.OutputPaths = []string{"stdout"}
+	}
+	return cfg, nil
+}
+
+// NewZapLogger 实例化一个 *zap.Logger，该实例用于注入 LoggerMiddleware 等
+//
+// 日志级别由 levelController 控制，可在运行时调整。
+// 返回的 cleanup 函数负责 Sync 日志缓冲并关闭日志文件。
+func NewZapLogger(
+	isDebug config.IsDebug,
+	cfg *Config,
+	levelController *LevelController,
+) (zapLogger *zap.Logger, cleanup func(), err error) {
+	encoder, err := newEncoder(isDebug, cfg.Encoding)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var closers []io.Closer
+	output, outputClosers, err := openSinks(cfg.OutputPaths, cfg.Rotate)
+	if err != nil {
+		return nil, nil, err
+	}
+	closers = append(closers, outputClosers...)
+
+	core := zapcore.NewCore(encoder, output, levelController)
+	if len(cfg.ErrorOutputPaths) > 0 {
+		errorOutput, errorClosers, err := openSinks(cfg.ErrorOutputPaths, cfg.Rotate)
+		if err != nil {
+			closeAll(closers)
+			return nil, nil, err
+		}
+		closers = append(closers, errorClosers...)
+		errorLevel := zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+			return l >= zapcore.ErrorLevel && levelController.Enabled(l)
+		})
+		core = zapcore.NewTee(core, zapcore.NewCore(encoder.Clone(), errorOutput, errorLevel))
+	}
+	if cfg.Sampling.Initial > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, cfg.Sampling.Initial, cfg.Sampling.Thereafter)
+	}
+	core = levelController.WrapCore(core)
+
+	options := []zap.Option{zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel)}
+	if isDebug {
+		options = append(options, zap.Development())
+	}
+	for key, value := range cfg.Fields {
+		options = append(options, zap.Fields(zap.String(key, value)))
+	}
+
+	zapLogger = zap.New(core, options...)
+	cleanup = func() {
+		// Sync 标准输出时可能返回 `invalid argument` 等错误，仅做提示
+		if err := zapLogger.Sync(); err != nil {
+			fmt.Printf("zap sync() error: %s\n", err)
+		}
+		closeAll(closers)
+	}
+	return zapLogger, cleanup, nil
+}
+
+// newEncoder 根据编码格式实例化 zapcore.Encoder
+func newEncoder(isDebug config.IsDebug, encoding string) (zapcore.Encoder, error) {
+	var encoderConfig zapcore.EncoderConfig
+	if isDebug {
+		encoderConfig = zap.NewDevelopmentEncoderConfig()
+	} else {
+		encoderConfig = zap.NewProductionEncoderConfig()
+	}
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	switch encoding {
+	case "json":
+		return zapcore.NewJSONEncoder(encoderConfig), nil
+	case "console":
+		if isDebug {
+			encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		return zapcore.NewConsoleEncoder(encoderConfig), nil
+	default:
+		return nil, errors.Errorf("unsupported log encoding `%s`", encoding)
+	}
+}
+
+// openSinks 打开所有日志输出路径。stdout、stderr 直接输出，其他路径视为文件并使用 lumberjack 切割。
+func openSinks(paths []string, rotate RotateConfig) (zapcore.WriteSyncer, []io.Closer, error) {
+	var (
+		syncers []zapcore.WriteSyncer
+		closers []io.Closer
+	)
+	for _, path := range paths {
+		switch path {
+		case "":
+			closeAll(closers)
+			return nil, nil, errors.New("log output path can not be empty")
+		case "stdout":
+			syncers = append(syncers, zapcore.Lock(os.Stdout))
+		case "stderr":
+			syncers = append(syncers, zapcore.Lock(os.Stderr))
+		default:
+			fileLogger := &lumberjack.Logger{
+				Filename:   path,
+				MaxSize:    rotate.MaxSize,
+				MaxAge:     rotate.MaxAge,
+				MaxBackups: rotate.MaxBackups,
+				LocalTime:  rotate.LocalTime,
+				Compress:   rotate.Compress,
+			}
+			syncers = append(syncers, zapcore.AddSync(fileLogger))
+			closers = append(closers, fileLogger)
+		}
+	}
+	return zapcore.NewMultiWriteSyncer(syncers...), closers, nil
+}
+
+// closeAll 关闭所有日志文件
+func closeAll(closers []io.Closer) {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			fmt.Printf("close log file error: %s\n", err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package logger_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"project/app/pkg/config"
+	"project/app/pkg/logger"
+	"project/app/pkg/requestid"
+	"strings"
+	"testing"
+)
+
+func TestNewZapLogger(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "logger")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+	appLog := filepath.Join(dir, "app.log")
+	errorLog := filepath.Join(dir, "error.log")
+
+	v := viper.New()
+	v.Set("log", map[string]interface{}{
+		"encoding":         "json",
+		"level":            "info",
+		"outputPaths":      []string{appLog},
+		"errorOutputPaths": []string{errorLog},
+		"fields":           map[string]string{"service": "sample"},
+	})
+	zapLogger, cleanup, err := newZapLogger(v)
+	a.Nil(err)
+	zapLogger.Debug("debug")
+	zapLogger.Info("info")
+	zapLogger.Error("error")
+	cleanup()
+
+	lines := readLines(t, appLog)
+	a.Len(lines, 2)
+	a.Equal("info", lines[0]["msg"])
+	a.Equal("sample", lines[0]["service"])
+	a.Equal("error", lines[1]["msg"])
+
+	lines = readLines(t, errorLog)
+	a.Len(lines, 1)
+	a.Equal("error", lines[0]["msg"])
+}
+
+func TestNewZapLogger_InvalidConfig(t *testing.T) {
+	a := assert.New(t)
+
+	v := viper.New()
+	v.Set("log.level", "verbose")
+	_, _, err := newZapLogger(v)
+	a.NotNil(err)
+
+	v = viper.New()
+	v.Set("log.encoding", "xml")
+	_, _, err = newZapLogger(v)
+	a.NotNil(err)
+
+	// 采样时 thereafter 须大于 0
+	v = viper.New()
+	v.Set("log.sampling.initial", 10)
+	_, _, err = newZapLogger(v)
+	a.Equal([]string{"`log.sampling.thereafter` is required when `log.sampling.initial` is set"}, config.Problems(err))
+}
+
+func newZapLogger(v *viper.Viper) (*zap.Logger, func(), error) {
+	cfg, err := logger.NewConfig(false, v)
+	if err != nil {
+		return nil, nil, err
+	}
+	levelController, err := logger.NewLevelController(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return logger.NewZapLogger(false, cfg, levelController)
+}
+
+func readLines(t *testing.T, path string) []map[string]interface{} {
+	t.Helper()
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		fields := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &fields); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, fields)
+	}
+	return lines
+}
+
+func TestWithContext(t *testing.T) {
+	a := assert.New(t)
+	core, logs := observer.New(zapcore.DebugLevel)
+	zapLogger := zap.New(core)
+
+	logger.WithContext(context.Background(), zapLogger).Info("without")
+	logger.WithContext(requestid.NewContext(context.Background(), "req-1"), zapLogger).Info("with")
+	a.NotContains(logs.All()[0].ContextMap(), "request_id")
+	a.Equal("req-1", logs.All()[1].ContextMap()["request_id"])
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+	"time"
+)
+
+// HttpMetrics 为 http 请求指标
+type HttpMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// httpLabels 为 http 请求指标的标签：路由模板、http method、http status、业务错误码名称（e.CodeDetail.Status）
+var httpLabels = []string{"route", "method", "status", "code"}
+
+// NewHttpMetrics 实例化 http 请求指标并注册到 registry
+func NewHttpMetrics(cfg *Config, registry *prometheus.Registry) (*HttpMetrics, error) {
+	m := &HttpMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.Namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Total number of HTTP requests.",
+		}, httpLabels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: cfg.Namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency in seconds.",
+			Buckets:   cfg.Buckets,
+		}, httpLabels),
+	}
+	for _, collector := range []prometheus.Collector{m.requests, m.duration} {
+		if err := registry.Register(collector); err != nil {
+			return nil, errors.Wrap(err, "register http metrics failed")
+		}
+	}
+	return m, nil
+}
+
+// Observe 记录一次 http 请求。route 为路由模板（例：/sms/login），code 为业务错误码名称，未经过 success()、fail() 的请求为空
+func (m *HttpMetrics) Observe(route, method string, status int, code string, latency time.Duration) {
+	labels := prometheus.Labels{
+		"route":  route,
+		"method": method,
+		"status": strconv.Itoa(status),
+		"code":   code,
+	}
+	m.requests.With(labels).Inc()
+	m.duration.With(labels).Observe(latency.Seconds())
+}
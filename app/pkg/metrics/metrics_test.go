@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewConfig(t *testing.T) {
+	a := assert.New(t)
+
+	v := viper.New()
+	cfg, err := NewConfig(v)
+	a.Nil(err)
+	a.NotEmpty(cfg.Buckets)
+
+	v.Set("metrics.buckets", []float64{0.1, 0.05})
+	_, err = NewConfig(v)
+	a.NotNil(err)
+}
+
+func TestHttpMetrics(t *testing.T) {
+	a := assert.New(t)
+	registry, err := NewRegistry()
+	a.Nil(err)
+	m, err := NewHttpMetrics(&Config{Namespace: "app", Buckets: []float64{0.1, 1}}, registry)
+	a.Nil(err)
+
+	m.Observe("/sms/login", http.MethodPost, http.StatusOK, "OK", 50*time.Millisecond)
+	m.Observe("/sms/login", http.MethodPost, http.StatusOK, "OK", 500*time.Millisecond)
+
+	a.Nil(testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP app_http_requests_total Total number of HTTP requests.
+# TYPE app_http_requests_total counter
+app_http_requests_total{code="OK",method="POST",route="/sms/login",status="200"} 2
+`), "app_http_requests_total"))
+	count, err := testutil.GatherAndCount(registry, "app_http_request_duration_seconds")
+	a.Nil(err)
+	a.Equal(1, count)
+
+	// 重复注册同名指标失败
+	_, err = NewHttpMetrics(&Config{Namespace: "app"}, registry)
+	a.NotNil(err)
+}
+
+func TestSmsMetrics(t *testing.T) {
+	a := assert.New(t)
+	registry, err := NewRegistry()
+	a.Nil(err)
+	m, err := NewSmsMetrics(&Config{}, registry)
+	a.Nil(err)
+
+	m.ObserveSend("aliyun", "login", nil)
+	m.ObserveSend("aliyun", "login", errors.New("send failed"))
+	m.ObserveRateLimited("login")
+	m.ObserveVerify("login", true)
+	m.ObserveVerify("login", false)
+	m.ObserveVerify("login", false)
+
+	a.Equal(1.0, testutil.ToFloat64(m.sends.WithLabelValues("aliyun", "login", OutcomeSuccess)))
+	a.Equal(1.0, testutil.ToFloat64(m.sends.WithLabelValues("aliyun", "login", OutcomeFailure)))
+	a.Equal(1.0, testutil.ToFloat64(m.rateLimited.WithLabelValues("login")))
+	a.Equal(2.0, testutil.ToFloat64(m.verifications.WithLabelValues("login", OutcomeFailure)))
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SmsMetrics 为短信验证码业务指标
+type SmsMetrics struct {
+	sends         *prometheus.CounterVec
+	rateLimited   *prometheus.CounterVec
+	verifications *prometheus.CounterVec
+}
+
+// NewSmsMetrics 实例化短信验证码业务指标并注册到 registry
+func NewSmsMetrics(cfg *Config, registry *prometheus.Registry) (*SmsMetrics, error) {
+	m := &SmsMetrics{
+		sends: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.Namespace,
+			Subsystem: "sms",
+			Name:      "sends_total",
+			Help:      "Total number of SMS verification code sends by provider, scene and outcome.",
+		}, []string{"provider", "scene", "outcome"}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.Namespace,
+			Subsystem: "sms",
+			Name:      "rate_limited_total",
+			Help:      "Total number of SMS send requests rejected by rate limit.",
+		}, []string{"scene"}),
+		verifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: cfg.Namespace,
+			Subsystem: "sms",
+			Name:      "verifications_total",
+			Help:      "Total number of SMS verification code verifications by scene and outcome.",
+		}, []string{"scene", "outcome"}),
+	}
+	for _, collector := range []prometheus.Collector{m.sends, m.rateLimited, m.verifications} {
+		if err := registry.Register(collector); err != nil {
+			return nil, errors.Wrap(err, "register sms metrics failed")
+		}
+	}
+	return m, nil
+}
+
+// ObserveSend 记录一次短信验证码发送，provider 为短信服务商（例：aliyun），scene 为业务场景（例：login）
+func (m *SmsMetrics) ObserveSend(provider, scene string, err error) {
+	m.sends.WithLabelValues(provider, scene, outcome(err == nil)).Inc()
+}
+
+// ObserveRateLimited 记录一次因发送频率超限而被拒绝的发送请求
+func (m *SmsMetrics) ObserveRateLimited(scene string) {
+	m.rateLimited.WithLabelValues(scene).Inc()
+}
+
+// ObserveVerify 记录一次短信验证码验证
+func (m *SmsMetrics) ObserveVerify(scene string, ok bool) {
+	m.verifications.WithLabelValues(scene, outcome(ok)).Inc()
+}
+
+func outcome(ok bool) string {
+	if ok {
+		return OutcomeSuccess
+	}
+	return OutcomeFailure
+}
@@ -0,0 +1,203 @@
+// 本包用于列表接口的游标分页（谷歌 API 设计指南，see: https://cloud.google.com/apis/design/design_patterns#list_pagination）：
+// 请求参数 page_size、page_token，响应 next_page_token。
+//
+// page_token 为 HMAC 签名的不透明字符串，记录上一页最后一条记录的排序键（游标），客户端无法伪造、篡改；
+// page_token 与列表请求的 scope（资源类型及 filter、order_by 等影响结果的参数）绑定，只能用于相同 scope 的请求。
+
+package pagination
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"project/app/pkg/config"
+	"strings"
+	"time"
+)
+
+// Config 为分页配置，对应配置文件中的 `pagination` 节点
+type Config struct {
+	// 签名 page_token 的密钥（机密配置），修改后已签发的 page_token 全部失效
+	Secret string `mapstructure:"secret" validate:"required"`
+	// 未指定 page_size 时的每页数量
+	DefaultPageSize int `mapstructure:"defaultPageSize" validate:"gt=0"`
+	// 每页数量上限，page_size 超过上限时按上限返回
+	MaxPageSize int `mapstructure:"maxPageSize" validate:"gt=0"`
+	// page_token 有效期，为 0 时不过期
+	TokenTTL time.Duration `mapstructure:"tokenTTL" validate:"gte=0"`
+}
+
+// NewConfig 从 viper 中读取分页配置
+func NewConfig(v *viper.Viper) (*Config, error) {
+	cfg := &Config{DefaultPageSize: 20, MaxPageSize: 100, TokenTTL: 24 * time.Hour}
+	if err := config.Unmarshal(v, "pagination", cfg); err != nil {
+		return nil, err
+	}
+	if cfg.DefaultPageSize > cfg.MaxPageSize {
+		return nil, &config.ValidationError{Problems: []string{
+			"`pagination.defaultPageSize` must be less than or equal to `pagination.maxPageSize`",
+		}}
+	}
+	return cfg, nil
+}
+
+// 请求参数名称
+const (
+	FieldPageSize  = "page_size"
+	FieldPageToken = "page_token"
+)
+
+// Error 为分页参数错误，应响应 e.CodeInvalidArgument 错误及 e.BadRequest 错误详情
+type Error struct {
+	// 错误的请求参数：page_size、page_token
+	Field string
+	// 错误描述
+	Description string
+}
+
+func (err *Error) Error() string {
+	return err.Description
+}
+
+// Paginator 解析分页参数、签发 page_token
+type Paginator struct {
+	cfg *Config
+	now func() time.Time
+}
+
+func NewPaginator(cfg *Config) *Paginator {
+	return &Paginator{cfg: cfg, now: time.Now}
+}
+
+// payload 为 page_token 的内容
+type payload struct {
+	// scope 摘要
+	Scope string `json:"s"`
+	// 游标
+	Cursor json.RawMessage `json:"c"`
+	// 过期时间（unix 秒），为 0 时不过期
+	Expire int64 `json:"e,omitempty"`
+}
+
+// Parse 解析请求参数 page_size、page_token，参数不合法时返回 *Error：
+//
+//   - page_size 为 0 时使用默认值，超过上限时按上限返回，为负数时返回错误
+//   - page_token 为空时表示第一页；签名不正确、已过期或 scope 与签发时不同时返回错误
+func (p *Paginator) Parse(pageSize int, pageToken string, scope string) (*Page, error) {
+	switch {
+	case pageSize < 0:
+		return nil, &Error{Field: FieldPageSize, Description: FieldPageSize + "必须大于或等于0"}
+	case pageSize == 0:
+		pageSize = p.cfg.DefaultPageSize
+	case pageSize > p.cfg.MaxPageSize:
+		pageSize = p.cfg.MaxPageSize
+	}
+	page := &Page{Size: pageSize, paginator: p, scope: scope}
+	if pageToken == "" {
+		return page, nil
+	}
+
+	data, err := p.verify(pageToken)
+	if err != nil {
+		return nil, &Error{Field: FieldPageToken, Description: FieldPageToken + "无效"}
+	}
+	var pl payload
+	if err := json.Unmarshal(data, &pl); err != nil {
+		return nil, &Error{Field: FieldPageToken, Description: FieldPageToken + "无效"}
+	}
+	if pl.Expire != 0 && p.now().Unix() > pl.Expire {
+		return nil, &Error{Field: FieldPageToken, Description: FieldPageToken + "已过期，请从第一页重新查询"}
+	}
+	if pl.Scope != scopeDigest(scope) {
+		return nil, &Error{Field: FieldPageToken, Description: FieldPageToken + "与查询条件不匹配，请从第一页重新查询"}
+	}
+	page.cursor = pl.Cursor
+	return page, nil
+}
+
+// token 签发 page_token，cursor 为游标（可 json 编码的值）
+func (p *Paginator) token(scope string, cursor interface{}) (string, error) {
+	c, err := json.Marshal(cursor)
+	if err != nil {
+		return "", errors.Wrap(err, "encode page cursor failed")
+	}
+	pl := payload{Scope: scopeDigest(scope), Cursor: c}
+	if p.cfg.TokenTTL > 0 {
+		pl.Expire = p.now().Add(p.cfg.TokenTTL).Unix()
+	}
+	data, err := json.Marshal(pl)
+	if err != nil {
+		return "", errors.Wrap(err, "encode page token failed")
+	}
+	return base64.RawURLEncoding.EncodeToString(data) + "." + base64.RawURLEncoding.EncodeToString(p.sign(data)), nil
+}
+
+// verify 校验 page_token 的签名，返回其内容
+func (p *Paginator) verify(pageToken string) ([]byte, error) {
+	parts := strings.Split(pageToken, ".")
+	if len(parts) != 2 {
+		return nil, errors.New("malformed page token")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(signature, p.sign(data)) {
+		return nil, errors.New("invalid page token signature")
+	}
+	return data, nil
+}
+
+func (p *Paginator) sign(data []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(p.cfg.Secret))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// scopeDigest 返回 scope 的摘要，避免 page_token 中包含 filter 等请求参数原文
+func scopeDigest(scope string) string {
+	sum := sha256.Sum256([]byte(scope))
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+// Page 为一次列表请求的分页参数
+type Page struct {
+	// 每页数量（已按默认值、上限调整）
+	Size int
+
+	paginator *Paginator
+	scope     string
+	cursor    json.RawMessage // 为空时表示第一页
+}
+
+// First 判断是否为第一页
+func (page *Page) First() bool {
+	return len(page.cursor) == 0
+}
+
+// Cursor 将游标解码到 v（须与 NextToken 的参数类型相同），第一页时 v 保持不变
+func (page *Page) Cursor(v interface{}) error {
+	if page.First() {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(page.cursor))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		// 签名正确但无法解码，通常为游标结构变更前签发的 page_token
+		return &Error{Field: FieldPageToken, Description: FieldPageToken + "无效"}
+	}
+	return nil
+}
+
+// NextToken 签发下一页的 page_token，cursor 为本页最后一条记录的排序键
+func (page *Page) NextToken(cursor interface{}) (string, error) {
+	return page.paginator.token(page.scope, cursor)
+}
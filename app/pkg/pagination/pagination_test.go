@@ -0,0 +1,72 @@
+package pagination
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testCursor struct {
+	Id   int64     `json:"id"`
+	Time time.Time `json:"time"`
+}
+
+func TestPaginator(t *testing.T) {
+	a := assert.New(t)
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	paginator := NewPaginator(&Config{Secret: "secret", DefaultPageSize: 20, MaxPageSize: 100, TokenTTL: time.Hour})
+	paginator.now = func() time.Time { return now }
+
+	// page_size
+	page, err := paginator.Parse(0, "", "users")
+	a.Nil(err)
+	a.Equal(20, page.Size)
+	a.True(page.First())
+	page, err = paginator.Parse(1000, "", "users")
+	a.Nil(err)
+	a.Equal(100, page.Size)
+	_, err = paginator.Parse(-1, "", "users")
+	a.Equal(FieldPageSize, err.(*Error).Field)
+
+	// 游标往返
+	cursor := testCursor{Id: 1<<62 + 1, Time: now}
+	token, err := page.NextToken(cursor)
+	a.Nil(err)
+	page, err = paginator.Parse(10, token, "users")
+	a.Nil(err)
+	a.False(page.First())
+	var decoded testCursor
+	a.Nil(page.Cursor(&decoded))
+	a.Equal(cursor.Id, decoded.Id)
+	a.True(cursor.Time.Equal(decoded.Time))
+
+	invalid := func(pageToken, scope string) string {
+		_, err := paginator.Parse(10, pageToken, scope)
+		if !a.NotNil(err) {
+			return ""
+		}
+		a.Equal(FieldPageToken, err.(*Error).Field)
+		return err.Error()
+	}
+	// 篡改、伪造
+	parts := strings.Split(token, ".")
+	invalid(parts[0]+"x."+parts[1], "users")
+	invalid(token+"x", "users")
+	invalid("abc", "users")
+	other := NewPaginator(&Config{Secret: "other", DefaultPageSize: 20, MaxPageSize: 100})
+	otherToken, _ := (&Page{paginator: other, scope: "users"}).NextToken(cursor)
+	invalid(otherToken, "users")
+	// scope 不同
+	a.Contains(invalid(token, "users?filter=x"), "不匹配")
+	// 过期
+	now = now.Add(2 * time.Hour)
+	a.Contains(invalid(token, "users"), "过期")
+
+	// 游标结构不匹配
+	paginator.cfg.TokenTTL = 0
+	token, _ = page.NextToken(map[string]string{"name": "x"})
+	page, err = paginator.Parse(10, token, "users")
+	a.Nil(err)
+	a.NotNil(page.Cursor(&decoded))
+}
@@ -0,0 +1,31 @@
+package panicreport
+
+import (
+	"go.uber.org/zap"
+)
+
+// LogNotifier 将告警记录为 error 级别日志
+type LogNotifier struct {
+	zapLogger *zap.Logger
+}
+
+var _ Notifier = new(LogNotifier)
+
+func NewLogNotifier(zapLogger *zap.Logger) *LogNotifier {
+	return &LogNotifier{zapLogger: zapLogger.Named("panicreport")}
+}
+
+// Notify 记录告警日志
+func (notifier *LogNotifier) Notify(report Report) error {
+	notifier.zapLogger.Error(
+		"panic threshold exceeded, possible crash loop",
+		zap.String("fingerprint", report.Fingerprint),
+		zap.String("message", report.Message),
+		zap.Int64("count", report.Count),
+		zap.Time("first_seen", report.FirstSeen),
+		zap.Time("last_seen", report.LastSeen),
+		zap.Strings("stack", report.Stack),
+		zap.String("path", report.Sample.Path),
+	)
+	return nil
+}
@@ -0,0 +1,297 @@
+// 本包用于汇总 panic 报告：按调用栈指纹对 panic 分组计数，持久化到本地文件，
+// 并在同一指纹的 panic 短时间内频繁发生（疑似崩溃循环）时触发告警。
+
+package panicreport
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"project/app/pkg/config"
+	"project/app/pkg/redact"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Config 为 panic 报告配置，对应配置文件中的 `panicReport` 节点
+type Config struct {
+	// 持久化文件路径，为空时仅保存在内存中
+	File string `mapstructure:"file"`
+	// 计算指纹时使用的栈顶帧数
+	FingerprintFrames int `mapstructure:"fingerprintFrames" validate:"gt=0"`
+	// 报告中保留的栈帧数
+	StackFrames int `mapstructure:"stackFrames" validate:"gt=0"`
+	// 告警阈值：同一指纹的 panic 在 Window 时间内发生 Threshold 次即触发告警，为 0 时不告警
+	Threshold int `mapstructure:"threshold" validate:"gte=0"`
+	// 告警统计窗口
+	Window time.Duration `mapstructure:"window" validate:"gt=0"`
+	// 持久化间隔
+	FlushInterval time.Duration `mapstructure:"flushInterval" validate:"gt=0"`
+}
+
+// NewConfig 从 viper 中读取 panic 报告配置
+func NewConfig(v *viper.Viper) (*Config, error) {
+	cfg := &Config{
+		FingerprintFrames: 5,
+		StackFrames:       32,
+		Threshold:         10,
+		Window:            time.Minute,
+		FlushInterval:     5 * time.Second,
+	}
+	if err := config.Unmarshal(v, "panicReport", cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Report 为同一指纹的 panic 汇总报告
+type Report struct {
+	// 指纹：栈顶若干帧 sha1 的前 16 位十六进制字符
+	Fingerprint string `json:"fingerprint"`
+	// 最近一次 panic 的错误信息
+	Message string `json:"message"`
+	// 累计发生次数
+	Count int64 `json:"count"`
+	// 首次、最近一次发生时间
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	// 最近一次 panic 的调用栈
+	Stack []string `json:"stack"`
+	// 最近一次 panic 的请求信息
+	Sample Sample `json:"sample"`
+
+	recent []time.Time // 统计窗口内的发生时间，用于告警
+	notify time.Time   // 最近一次告警时间
+}
+
+// Sample 为发生 panic 的请求信息样本
+type Sample struct {
+	RequestId string `json:"request_id,omitempty"`
+	Method    string `json:"method,omitempty"`
+	Path      string `json:"path,omitempty"`
+	Route     string `json:"route,omitempty"`
+	ClientIP  string `json:"client_ip,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// Notifier 为告警通知接口，可实现为日志、邮件、IM 机器人等
+type Notifier interface {
+	// Notify 通知某指纹的 panic 在统计窗口内发生次数达到阈值
+	Notify(report Report) error
+}
+
+// Recorder 负责记录、汇总、持久化 panic 报告
+type Recorder struct {
+	cfg      *Config
+	store    Store
+	notifier Notifier
+	redactor *redact.Redactor
+	// 告警、持久化失败日志，logger 名称：panicreport
+	zapLogger *zap.Logger
+
+	mu      sync.Mutex
+	reports map[string]*Report
+	dirty   bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRecorder 实例化一个 *Recorder，并从持久化文件中加载历史报告。
+// 报告中的 panic 信息、请求路径在记录前经 redactor 脱敏；告警、持久化失败时记录到 zapLogger。
+//
+// 返回的 cleanup 函数负责停止定时持久化，并将未持久化的报告写入文件。
+func NewRecorder(cfg *Config, notifier Notifier, redactor *redact.Redactor, zapLogger *zap.Logger) (*Recorder, func(), error) {
+	var store Store = &memoryStore{}
+	if cfg.File != "" {
+		store = &FileStore{Path: cfg.File}
+	}
+	reports, err := store.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	recorder := &Recorder{
+		cfg:       cfg,
+		store:     store,
+		notifier:  notifier,
+		redactor:  redactor,
+		zapLogger: zapLogger.Named("panicreport"),
+		reports:   map[string]*Report{},
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	for _, report := range reports {
+		report := report
+		recorder.reports[report.Fingerprint] = &report
+	}
+	go recorder.loop()
+	return recorder, recorder.close, nil
+}
+
+// Record 记录一次 panic，返回该 panic 所属的报告
+func (recorder *Recorder) Record(err error, frames []runtime.Frame, sample Sample) Report {
+	now := time.Now()
+	fingerprint := Fingerprint(frames, recorder.cfg.FingerprintFrames)
+
+	recorder.mu.Lock()
+	report, ok := recorder.reports[fingerprint]
+	if !ok {
+		report = &Report{Fingerprint: fingerprint, FirstSeen: now}
+		recorder.reports[fingerprint] = report
+	}
+	report.Message = recorder.redactor.Error(err, false)
+	report.Count++
+	report.LastSeen = now
+	report.Stack = FormatFrames(topFrames(frames, recorder.cfg.StackFrames))
+	sample.Path = recorder.redactor.String(sample.Path)
+	report.Sample = sample
+	recorder.dirty = true
+
+	shouldNotify := recorder.checkThreshold(report, now)
+	snapshot := report.snapshot()
+	recorder.mu.Unlock()
+
+	if shouldNotify {
+		go func() {
+			if err := recorder.notifier.Notify(snapshot); err != nil {
+				recorder.zapLogger.Warn("panic report notify failed",
+					zap.String("panic_fingerprint", snapshot.Fingerprint), zap.Error(err))
+			}
+		}()
+	}
+	return snapshot
+}
+
+// checkThreshold 更新统计窗口，判断是否需要告警。同一指纹在一个统计窗口内最多告警一次。
+func (recorder *Recorder) checkThreshold(report *Report, now time.Time) bool {
+	if recorder.cfg.Threshold <= 0 {
+		return false
+	}
+	windowStart := now.Add(-recorder.cfg.Window)
+	recent := report.recent[:0]
+	for _, t := range report.recent {
+		if t.After(windowStart) {
+			recent = append(recent, t)
+		}
+	}
+	report.recent = append(recent, now)
+	if len(report.recent) < recorder.cfg.Threshold || report.notify.After(windowStart) {
+		return false
+	}
+	report.notify = now
+	return true
+}
+
+// List 返回所有报告，按最近一次发生时间倒序排列
+func (recorder *Recorder) List() []Report {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	reports := make([]Report, 0, len(recorder.reports))
+	for _, report := range recorder.reports {
+		reports = append(reports, report.snapshot())
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].LastSeen.After(reports[j].LastSeen)
+	})
+	return reports
+}
+
+// Get 返回指定指纹的报告
+func (recorder *Recorder) Get(fingerprint string) (Report, bool) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	report, ok := recorder.reports[fingerprint]
+	if !ok {
+		return Report{}, false
+	}
+	return report.snapshot(), true
+}
+
+// Flush 将报告写入持久化文件
+func (recorder *Recorder) Flush() error {
+	recorder.mu.Lock()
+	if !recorder.dirty {
+		recorder.mu.Unlock()
+		return nil
+	}
+	reports := make([]Report, 0, len(recorder.reports))
+	for _, report := range recorder.reports {
+		reports = append(reports, report.snapshot())
+	}
+	recorder.dirty = false
+	recorder.mu.Unlock()
+
+	if err := recorder.store.Save(reports); err != nil {
+		recorder.mu.Lock()
+		recorder.dirty = true
+		recorder.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// loop 定时持久化报告
+func (recorder *Recorder) loop() {
+	defer close(recorder.done)
+	ticker := time.NewTicker(recorder.cfg.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := recorder.Flush(); err != nil {
+				recorder.zapLogger.Error("panic report flush failed", zap.Error(err))
+			}
+		case <-recorder.stop:
+			return
+		}
+	}
+}
+
+// close 停止定时持久化，并将未持久化的报告写入文件
+func (recorder *Recorder) close() {
+	close(recorder.stop)
+	<-recorder.done
+	if err := recorder.Flush(); err != nil {
+		recorder.zapLogger.Error("panic report flush failed", zap.Error(err))
+	}
+}
+
+// snapshot 返回报告的副本
+func (report *Report) snapshot() Report {
+	snapshot := *report
+	snapshot.Stack = append([]string(nil), report.Stack...)
+	snapshot.recent = nil
+	return snapshot
+}
+
+// Fingerprint 根据栈顶 n 帧（函数名 + 文件:行号）计算 panic 指纹
+func Fingerprint(frames []runtime.Frame, n int) string {
+	h := sha1.New()
+	for _, entry := range FormatFrames(topFrames(frames, n)) {
+		_, _ = fmt.Fprintln(h, entry)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// FormatFrames 将调用栈格式化为 `函数名 文件:行号` 形式
+func FormatFrames(frames []runtime.Frame) []string {
+	entries := make([]string, 0, len(frames))
+	for _, frame := range frames {
+		entries = append(entries, fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line))
+	}
+	return entries
+}
+
+// topFrames 返回栈顶 n 帧
+func topFrames(frames []runtime.Frame, n int) []runtime.Frame {
+	if len(frames) > n {
+		return frames[:n]
+	}
+	return frames
+}
@@ -0,0 +1,130 @@
+package panicreport_test
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"project/app/pkg/panicreport"
+	"project/app/pkg/redact"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// notifier 记录所有告警，用于测试
+type notifier struct {
+	mu      sync.Mutex
+	reports []panicreport.Report
+}
+
+func (n *notifier) Notify(report panicreport.Report) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.reports = append(n.reports, report)
+	return nil
+}
+
+func (n *notifier) count() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.reports)
+}
+
+func newRedactor(t *testing.T) *redact.Redactor {
+	redactor, err := redact.New(redact.DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return redactor
+}
+
+func frames(function string, line int) []runtime.Frame {
+	return []runtime.Frame{
+		{Function: function, File: "/app/handler/ctrl.go", Line: line},
+		{Function: "github.com/gin-gonic/gin.(*Context).Next", File: "/gin/context.go", Line: 161},
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "panicreport")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+	cfg := &panicreport.Config{
+		File:              filepath.Join(dir, "reports.json"),
+		FingerprintFrames: 5,
+		StackFrames:       1,
+		Threshold:         3,
+		Window:            time.Minute,
+		FlushInterval:     time.Minute,
+	}
+	n := &notifier{}
+
+	recorder, cleanup, err := panicreport.NewRecorder(cfg, n, newRedactor(t), zap.NewNop())
+	a.Nil(err)
+	for i := 0; i < 5; i++ {
+		recorder.Record(errors.New("boom"), frames("handler.Send", 10), panicreport.Sample{Path: "/sms/login"})
+	}
+	other := recorder.Record(errors.New("send to 13812341234 failed"), frames("handler.Send", 20),
+		panicreport.Sample{Path: "/users/13812341234"})
+	// panic 信息、请求路径经脱敏后记录
+	a.Equal("send to 138****1234 failed", other.Message)
+	a.Equal("/users/138****1234", other.Sample.Path)
+
+	reports := recorder.List()
+	a.Len(reports, 2)
+	a.Equal(other.Fingerprint, reports[0].Fingerprint)
+	a.Equal(int64(5), reports[1].Count)
+	a.Equal("/sms/login", reports[1].Sample.Path)
+	a.Len(reports[1].Stack, 1)
+	// 达到阈值后，同一统计窗口内仅告警一次
+	a.Eventually(func() bool { return n.count() == 1 }, time.Second, 10*time.Millisecond)
+	cleanup()
+
+	// 重新加载持久化的报告
+	recorder, cleanup, err = panicreport.NewRecorder(cfg, n, newRedactor(t), zap.NewNop())
+	a.Nil(err)
+	defer cleanup()
+	report, ok := recorder.Get(reports[1].Fingerprint)
+	a.True(ok)
+	a.Equal(int64(5), report.Count)
+	a.Equal(reports[1].FirstSeen.Unix(), report.FirstSeen.Unix())
+	_, ok = recorder.Get("unknown")
+	a.False(ok)
+}
+
+// failingNotifier 告警总是失败
+type failingNotifier struct{}
+
+func (failingNotifier) Notify(report panicreport.Report) error {
+	return errors.New("webhook unavailable")
+}
+
+func TestRecorder_NotifyError(t *testing.T) {
+	a := assert.New(t)
+	core, logs := observer.New(zapcore.DebugLevel)
+	cfg := &panicreport.Config{FingerprintFrames: 5, StackFrames: 1, Threshold: 1, Window: time.Minute, FlushInterval: time.Minute}
+	recorder, cleanup, err := panicreport.NewRecorder(cfg, failingNotifier{}, newRedactor(t), zap.New(core))
+	a.Nil(err)
+	defer cleanup()
+
+	// 告警失败时记录日志，而非输出到标准输出
+	report := recorder.Record(errors.New("boom"), frames("handler.Send", 10), panicreport.Sample{})
+	a.Eventually(func() bool { return logs.Len() == 1 }, time.Second, 10*time.Millisecond)
+	entry := logs.All()[0]
+	a.Equal(zapcore.WarnLevel, entry.Level)
+	a.Equal("panicreport", entry.LoggerName)
+	a.Equal(report.Fingerprint, entry.ContextMap()["panic_fingerprint"])
+}
+
+func TestFingerprint(t *testing.T) {
+	a := assert.New(t)
+	a.Equal(panicreport.Fingerprint(frames("a", 1), 1), panicreport.Fingerprint(frames("a", 1)[:1], 5))
+	a.NotEqual(panicreport.Fingerprint(frames("a", 1), 5), panicreport.Fingerprint(frames("a", 2), 5))
+}
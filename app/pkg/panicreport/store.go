@@ -0,0 +1,79 @@
+package panicreport
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Store 为 panic 报告的持久化接口
+type Store interface {
+	// Load 加载所有报告
+	Load() ([]Report, error)
+	// Save 保存所有报告（全量覆盖）
+	Save(reports []Report) error
+}
+
+// FileStore 将 panic 报告以 JSON 格式保存到本地文件
+type FileStore struct {
+	Path string
+}
+
+var _ Store = new(FileStore)
+
+// Load 加载所有报告，文件不存在时返回空列表
+func (store *FileStore) Load() ([]Report, error) {
+	content, err := ioutil.ReadFile(store.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "read panic report file failed")
+	}
+	var reports []Report
+	if err := json.Unmarshal(content, &reports); err != nil {
+		return nil, errors.Wrapf(err, "decode panic report file `%s` failed", store.Path)
+	}
+	return reports, nil
+}
+
+// Save 保存所有报告。先写入临时文件再重命名，避免进程崩溃时写坏文件。
+func (store *FileStore) Save(reports []Report) error {
+	content, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "encode panic reports failed")
+	}
+	dir := filepath.Dir(store.Path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrap(err, "create panic report dir failed")
+	}
+	tmp, err := ioutil.TempFile(dir, filepath.Base(store.Path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "create panic report temp file failed")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return errors.Wrap(err, "write panic report temp file failed")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "close panic report temp file failed")
+	}
+	if err := os.Rename(tmp.Name(), store.Path); err != nil {
+		return errors.Wrap(err, "rename panic report file failed")
+	}
+	return nil
+}
+
+// memoryStore 不做持久化，用于未配置持久化文件时
+type memoryStore struct{}
+
+func (store *memoryStore) Load() ([]Report, error) {
+	return nil, nil
+}
+
+func (store *memoryStore) Save(reports []Report) error {
+	return nil
+}
@@ -0,0 +1,65 @@
+// 本包定义通过客户端证书（mTLS）认证的调用方身份，并在 context.Context 中传递
+
+package principal
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+)
+
+// contextKey 为 context.Context 中调用方身份对应的 key 类型，避免与其他包冲突
+type contextKey struct{}
+
+// Principal 为已校验的客户端证书所标识的调用方身份
+type Principal struct {
+	// 证书主题，例：CN=billing,O=example
+	Subject string `json:"subject"`
+	// 证书主题中的 CN
+	CommonName string `json:"common_name"`
+	// 证书主题中的 O
+	Organization []string `json:"organization,omitempty"`
+	// 证书 SAN 中的 DNS 名称
+	DNSNames []string `json:"dns_names,omitempty"`
+	// 证书 SAN 中的邮箱地址
+	EmailAddresses []string `json:"email_addresses,omitempty"`
+	// 证书 SAN 中的 URI，例：spiffe://example.org/billing
+	URIs []string `json:"uris,omitempty"`
+	// 证书签发者
+	Issuer string `json:"issuer"`
+	// 证书序列号（十进制）
+	SerialNumber string `json:"serial_number"`
+	// 证书 DER 编码的 sha256 指纹（十六进制）
+	Fingerprint string `json:"fingerprint"`
+}
+
+// FromCertificate 根据客户端证书生成调用方身份
+func FromCertificate(cert *x509.Certificate) *Principal {
+	sum := sha256.Sum256(cert.Raw)
+	p := &Principal{
+		Subject:        cert.Subject.String(),
+		CommonName:     cert.Subject.CommonName,
+		Organization:   cert.Subject.Organization,
+		DNSNames:       cert.DNSNames,
+		EmailAddresses: cert.EmailAddresses,
+		Issuer:         cert.Issuer.String(),
+		SerialNumber:   cert.SerialNumber.String(),
+		Fingerprint:    hex.EncodeToString(sum[:]),
+	}
+	for _, uri := range cert.URIs {
+		p.URIs = append(p.URIs, uri.String())
+	}
+	return p
+}
+
+// NewContext 返回携带调用方身份的 context.Context
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext 获取 context.Context 中携带的调用方身份，不存在时返回 nil
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(contextKey{}).(*Principal)
+	return p
+}
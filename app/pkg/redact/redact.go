@@ -0,0 +1,326 @@
+// 本包用于日志脱敏：在记录日志前，对错误信息、请求参数、请求体等内容中的敏感信息进行掩码处理。
+
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"net/http"
+	"net/url"
+	"project/app/pkg/config"
+	"regexp"
+	"strings"
+)
+
+// Config 为日志脱敏配置，对应配置文件中的 `log.redact` 节点
+type Config struct {
+	// 敏感字段名（不区分大小写），匹配的 JSON 字段、query 参数、表单参数的值将被完全掩码
+	Fields []string `mapstructure:"fields"`
+	// 敏感内容正则规则，匹配的内容将被部分掩码
+	Patterns []PatternConfig `mapstructure:"patterns" validate:"dive"`
+	// 允许记录的 http header 名称（不区分大小写），不在此列表中的 header 不会被记录
+	Headers []string `mapstructure:"headers"`
+}
+
+// PatternConfig 为单条正则脱敏规则
+type PatternConfig struct {
+	// 规则名称，仅用于配置错误提示
+	Name string `mapstructure:"name"`
+	// 正则表达式
+	Regexp string `mapstructure:"regexp" validate:"required"`
+	// 掩码时保留的前缀字符数
+	KeepPrefix int `mapstructure:"keepPrefix" validate:"gte=0"`
+	// 掩码时保留的后缀字符数
+	KeepSuffix int `mapstructure:"keepSuffix" validate:"gte=0"`
+}
+
+// DefaultConfig 为未配置 `log.redact` 时使用的默认脱敏规则
+var DefaultConfig = Config{
+	Fields: []string{
+		"sms_code", "verify_code", "password", "secret", "token", "access_token", "refresh_token", "authorization",
+	},
+	Patterns: []PatternConfig{
+		// 11位中国大陆手机号，例：13812341234 -> 138****1234
+		{Name: "cnCellPhoneNumber", Regexp: `\b1[3-9][0-9]{9}\b`, KeepPrefix: 3, KeepSuffix: 4},
+		// Bearer 令牌，例：Bearer abc.def -> Bearer *******
+		{Name: "bearerToken", Regexp: `(?i)\bbearer\s+[a-z0-9\-._~+/]+=*`, KeepPrefix: 7},
+	},
+	Headers: []string{
+		"Content-Type", "Content-Length", "Accept", "Accept-Language", "X-Request-Id", "X-Forwarded-For",
+	},
+}
+
+// fieldMask 为敏感字段值的掩码
+const fieldMask = "***"
+
+// Redactor 负责日志脱敏
+type Redactor struct {
+	fields map[string]struct{}
+	// 匹配 JSON 文本中敏感字段的值，用于无法解析（如：被截断）的 JSON，未配置敏感字段时为 nil
+	jsonFields *regexp.Regexp
+	patterns   []pattern
+	headers    []string
+}
+
+type pattern struct {
+	regexp     *regexp.Regexp
+	keepPrefix int
+	keepSuffix int
+}
+
+// NewRedactor 根据配置实例化一个 *Redactor，未配置 `log.redact` 时使用 DefaultConfig
+func NewRedactor(v *viper.Viper) (*Redactor, error) {
+	cfg := DefaultConfig
+	if v.IsSet("log.redact") {
+		cfg = Config{}
+		if err := config.Unmarshal(v, "log.redact", &cfg); err != nil {
+			return nil, err
+		}
+	}
+	return New(cfg)
+}
+
+// New 根据脱敏配置实例化一个 *Redactor
+func New(cfg Config) (*Redactor, error) {
+	redactor := &Redactor{fields: map[string]struct{}{}}
+	quoted := make([]string, 0, len(cfg.Fields))
+	for _, field := range cfg.Fields {
+		redactor.fields[strings.ToLower(field)] = struct{}{}
+		quoted = append(quoted, regexp.QuoteMeta(field))
+	}
+	if len(quoted) > 0 {
+		// 值为字符串（可能缺少结尾的引号）或其它标量
+		redactor.jsonFields = regexp.MustCompile(
+			`(?i)("(?:` + strings.Join(quoted, "|") + `)"\s*:\s*)(?:"(?:[^"\\]|\\.)*"?|[^,{}\[\]\s]+)`,
+		)
+	}
+	for _, p := range cfg.Patterns {
+		reg, err := regexp.Compile(p.Regexp)
+		if err != nil {
+			return nil, errors.Wrapf(err, "compile redact pattern `%s` failed", p.Name)
+		}
+		redactor.patterns = append(redactor.patterns, pattern{
+			regexp:     reg,
+			keepPrefix: p.KeepPrefix,
+			keepSuffix: p.KeepSuffix,
+		})
+	}
+	for _, header := range cfg.Headers {
+		redactor.headers = append(redactor.headers, http.CanonicalHeaderKey(header))
+	}
+	return redactor, nil
+}
+
+// Mask 掩码字符串中间部分，保留 keepPrefix 个前缀字符和 keepSuffix 个后缀字符。
+//
+// 例：Mask("13812341234", 3, 4) -> 138****1234
+// 字符串长度不足以保留前后缀时，整体掩码。
+func Mask(s string, keepPrefix, keepSuffix int) string {
+	runes := []rune(s)
+	if keepPrefix < 0 {
+		keepPrefix = 0
+	}
+	if keepSuffix < 0 {
+		keepSuffix = 0
+	}
+	if len(runes) <= keepPrefix+keepSuffix {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:keepPrefix]) +
+		strings.Repeat("*", len(runes)-keepPrefix-keepSuffix) +
+		string(runes[len(runes)-keepSuffix:])
+}
+
+// IsSensitiveField 判断字段名是否为敏感字段
+func (r *Redactor) IsSensitiveField(name string) bool {
+	_, ok := r.fields[strings.ToLower(name)]
+	return ok
+}
+
+// String 对字符串中匹配正则规则的内容进行掩码
+func (r *Redactor) String(s string) string {
+	for _, p := range r.patterns {
+		s = p.regexp.ReplaceAllStringFunc(s, func(match string) string {
+			return Mask(match, p.keepPrefix, p.keepSuffix)
+		})
+	}
+	return s
+}
+
+// Error 返回脱敏后的错误信息。verbose 为 true 时使用 `%+v` 格式化（包含 pkg/errors 记录的堆栈）。
+func (r *Redactor) Error(err error, verbose bool) string {
+	if err == nil {
+		return ""
+	}
+	if verbose {
+		return r.String(fmt.Sprintf("%+v", err))
+	}
+	return r.String(err.Error())
+}
+
+// Values 返回脱敏后的 url.Values 编码字符串，用于 query string、表单请求体
+func (r *Redactor) Values(values url.Values) string {
+	redacted := make(url.Values, len(values))
+	for key, items := range values {
+		for _, item := range items {
+			if r.IsSensitiveField(key) {
+				redacted.Add(key, fieldMask)
+			} else {
+				redacted.Add(key, r.String(item))
+			}
+		}
+	}
+	return redacted.Encode()
+}
+
+// Query 返回脱敏后的 query string。query string 解析失败时，仅按正则规则脱敏。
+func (r *Redactor) Query(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return r.String(rawQuery)
+	}
+	return r.Values(values)
+}
+
+// Body 根据 Content-Type 返回脱敏后的请求体/响应体：
+// - JSON：敏感字段值完全掩码，其他字符串值按正则规则脱敏；无法解析（如：被截断）时敏感字段的值同样完全掩码
+// - 表单：同 Values
+// - 其他：按正则规则脱敏
+func (r *Redactor) Body(contentType string, body []byte) string {
+	switch {
+	case strings.Contains(contentType, "json"):
+		var data interface{}
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&data); err != nil {
+			return r.jsonText(string(body))
+		}
+		redacted, err := json.Marshal(r.jsonValue(data))
+		if err != nil {
+			return r.jsonText(string(body))
+		}
+		return string(redacted)
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		return r.Query(string(body))
+	default:
+		return r.String(string(body))
+	}
+}
+
+// jsonText 按文本匹配脱敏无法解析的 JSON：敏感字段（`"<field>": <value>`）的值完全掩码，其他内容按正则规则脱敏
+func (r *Redactor) jsonText(s string) string {
+	if r.jsonFields != nil {
+		s = r.jsonFields.ReplaceAllString(s, `${1}"`+fieldMask+`"`)
+	}
+	return r.String(s)
+}
+
+// jsonValue 递归脱敏 JSON 值
+func (r *Redactor) jsonValue(data interface{}) interface{} {
+	switch value := data.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			if r.IsSensitiveField(key) {
+				value[key] = fieldMask
+			} else {
+				value[key] = r.jsonValue(item)
+			}
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = r.jsonValue(item)
+		}
+		return value
+	case string:
+		return r.String(value)
+	case json.Number:
+		// 数字形式的敏感信息（如手机号）掩码后以字符串形式记录
+		if redacted := r.String(value.String()); redacted != value.String() {
+			return redacted
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+// settingSecretKeywords 为配置项名称中表示机密信息的关键字（小写）
+var settingSecretKeywords = []string{"secret", "token", "password", "passwd", "credential", "privatekey"}
+
+// Settings 返回脱敏后的配置项（如：viper.AllSettings()），用于展示生效中的配置：
+// 名称为敏感字段或包含 secret、token、password 等关键字的配置项（不区分大小写），值完全掩码，其他字符串值按正则规则脱敏。
+//
+// 返回新的 map，不修改 settings。
+func (r *Redactor) Settings(settings map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(settings))
+	for key, value := range settings {
+		if r.isSecretSetting(key) {
+			result[key] = fieldMask
+		} else {
+			result[key] = r.settingValue(value)
+		}
+	}
+	return result
+}
+
+// isSecretSetting 判断配置项是否为机密信息
+func (r *Redactor) isSecretSetting(key string) bool {
+	if r.IsSensitiveField(key) {
+		return true
+	}
+	key = strings.ToLower(key)
+	for _, keyword := range settingSecretKeywords {
+		if strings.Contains(key, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// settingValue 递归脱敏配置项的值
+func (r *Redactor) settingValue(data interface{}) interface{} {
+	switch value := data.(type) {
+	case map[string]interface{}:
+		return r.Settings(value)
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, item := range value {
+			result[i] = r.settingValue(item)
+		}
+		return result
+	case []string:
+		result := make([]string, len(value))
+		for i, item := range value {
+			result[i] = r.String(item)
+		}
+		return result
+	case string:
+		return r.String(value)
+	default:
+		return value
+	}
+}
+
+// Headers 返回允许记录的 http header，敏感 header（如 Authorization）的值将被掩码
+func (r *Redactor) Headers(header http.Header) map[string]string {
+	res := map[string]string{}
+	for _, name := range r.headers {
+		value := header.Get(name)
+		if value == "" {
+			continue
+		}
+		if r.IsSensitiveField(name) {
+			res[name] = fieldMask
+		} else {
+			res[name] = r.String(value)
+		}
+	}
+	return res
+}
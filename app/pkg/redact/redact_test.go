@@ -0,0 +1,81 @@
+package redact_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"project/app/pkg/config"
+	"project/app/pkg/redact"
+	"testing"
+)
+
+func TestMask(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("138****1234", redact.Mask("13812341234", 3, 4))
+	a.Equal("***", redact.Mask("abc", 2, 2))
+	a.Equal("******", redact.Mask("123456", 0, 0))
+}
+
+func TestRedactor(t *testing.T) {
+	a := assert.New(t)
+	// 配置文件模板中的规则应与默认规则一致
+	v, err := config.NewViper(nil, "../../config/template.yaml")
+	a.Nil(err)
+	r, err := redact.NewRedactor(v)
+	a.Nil(err)
+
+	a.Equal("send to 138****1234 failed", r.String("send to 13812341234 failed"))
+	a.Equal("Bearer ***", r.String("Bearer abc"))
+	a.Equal("phone=138%2A%2A%2A%2A1234&sms_code=%2A%2A%2A", r.Query("phone=13812341234&sms_code=123456"))
+
+	a.JSONEq(
+		`{"cn_cell_phone_number":"138****1234","list":[{"password":"***"},"138****1234"],"n":1}`,
+		r.Body("application/json; charset=utf-8",
+			[]byte(`{"cn_cell_phone_number":"13812341234","list":[{"password":"x"},13812341234],"n":1}`)),
+	)
+	// 被截断的 JSON
+	a.Equal(`{"Sms_Code": "***", "token":"***", "n":1, "password":"***"`,
+		r.Body("application/json", []byte(`{"Sms_Code": "123456", "token":"a\"b", "n":1, "password":"sec`)))
+	a.Equal(`{"sms_code":"***"`, r.Body("application/json", []byte(`{"sms_code":12345`)))
+	a.Equal("cn_cell_phone_number=138%2A%2A%2A%2A1234",
+		r.Body("application/x-www-form-urlencoded", []byte("cn_cell_phone_number=13812341234")))
+	a.Equal("tel:138****1234", r.Body("text/plain", []byte("tel:13812341234")))
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer abc")
+	header.Set("Content-Type", "application/json")
+	a.Equal(map[string]string{"Content-Type": "application/json"}, r.Headers(header))
+}
+
+func TestRedactor_Settings(t *testing.T) {
+	a := assert.New(t)
+	r, err := redact.New(redact.DefaultConfig)
+	a.Nil(err)
+
+	settings := map[string]interface{}{
+		"admin": map[string]interface{}{"token": "abc"},
+		"aliyunloginsms": map[string]interface{}{
+			"accesskeyid":     "id",
+			"accesskeysecret": "secret-value",
+		},
+		"addr":  []interface{}{":80", map[string]interface{}{"addr": ":443", "dbpassword": "x"}},
+		"phone": "13812341234",
+		"n":     1,
+	}
+	a.Equal(map[string]interface{}{
+		"admin": map[string]interface{}{"token": "***"},
+		"aliyunloginsms": map[string]interface{}{
+			"accesskeyid":     "id",
+			"accesskeysecret": "***",
+		},
+		"addr":  []interface{}{":80", map[string]interface{}{"addr": ":443", "dbpassword": "***"}},
+		"phone": "138****1234",
+		"n":     1,
+	}, r.Settings(settings))
+	// 不修改原配置
+	a.Equal("abc", settings["admin"].(map[string]interface{})["token"])
+}
+
+func TestNew_InvalidPattern(t *testing.T) {
+	_, err := redact.New(redact.Config{Patterns: []redact.PatternConfig{{Name: "bad", Regexp: "("}}})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,45 @@
+// 本包用于生成请求 ID，并在 context.Context 中传递请求 ID
+
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+)
+
+// contextKey 为 context.Context 中请求 ID 对应的 key 类型，避免与其他包冲突
+type contextKey struct{}
+
+// maxLength 为客户端传入的请求 ID 的最大长度
+const maxLength = 128
+
+// validRegexp 为客户端传入的请求 ID 的合法字符集
+var validRegexp = regexp.MustCompile(`^[A-Za-z0-9\-_.:]+$`)
+
+// Generate 生成一个新的请求 ID（32 位十六进制字符串）
+func Generate() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand 读取失败时，系统已处于不可用状态
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// IsValid 判断客户端传入的请求 ID 是否合法，避免日志注入及超长请求 ID
+func IsValid(id string) bool {
+	return len(id) > 0 && len(id) <= maxLength && validRegexp.MatchString(id)
+}
+
+// NewContext 返回携带请求 ID 的 context.Context
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext 获取 context.Context 中携带的请求 ID，不存在时返回空字符串
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
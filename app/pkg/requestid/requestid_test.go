@@ -0,0 +1,32 @@
+package requestid_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"project/app/pkg/requestid"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	a := assert.New(t)
+	id := requestid.Generate()
+	a.Len(id, 32)
+	a.True(requestid.IsValid(id))
+	a.NotEqual(id, requestid.Generate())
+}
+
+func TestIsValid(t *testing.T) {
+	a := assert.New(t)
+	a.True(requestid.IsValid("req-1_a.b:c"))
+	a.False(requestid.IsValid(""))
+	a.False(requestid.IsValid("req 1"))
+	a.False(requestid.IsValid("req\n1"))
+	a.False(requestid.IsValid(strings.Repeat("a", 129)))
+}
+
+func TestContext(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("", requestid.FromContext(context.Background()))
+	a.Equal("req-1", requestid.FromContext(requestid.NewContext(context.Background(), "req-1")))
+}
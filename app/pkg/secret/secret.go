@@ -0,0 +1,146 @@
+// 本包用于加密配置文件中的机密配置值：使用 AES-256-GCM 加密，密文格式为 `ENC[AES256_GCM,<base64(nonce|ciphertext)>]`，
+// 可直接写入 secret.yaml 等配置文件，读取配置时由 config.NewViper 透明解密。
+
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"github.com/pkg/errors"
+	"regexp"
+	"strings"
+)
+
+const (
+	// 密文前缀、后缀
+	prefix = "ENC[AES256_GCM,"
+	suffix = "]"
+	// 密钥字节数（AES-256）
+	keySize = 32
+)
+
+// pattern 匹配文本中的密文
+var pattern = regexp.MustCompile(`ENC\[AES256_GCM,[A-Za-z0-9+/=]*\]`)
+
+// Keys 为密钥环：第一个密钥用于加密，所有密钥均可用于解密，便于轮换密钥
+type Keys [][]byte
+
+// GenerateKey 生成一个随机密钥（base64 编码）
+func GenerateKey() (string, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", errors.Wrap(err, "generate key failed")
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// ParseKeys 解析以 `,` 分隔的 base64 编码密钥（每个密钥 32 字节），例：`newKey,oldKey`
+func ParseKeys(text string) (Keys, error) {
+	var keys Keys
+	for _, item := range strings.Split(text, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(item)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode key failed")
+		}
+		if len(key) != keySize {
+			return nil, errors.Errorf("key must be %d bytes, got %d", keySize, len(key))
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no key found")
+	}
+	return keys, nil
+}
+
+// IsEncrypted 判断配置值是否为密文
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix) && strings.HasSuffix(value, suffix)
+}
+
+// Encrypt 使用第一个密钥加密 plaintext，返回密文
+func (keys Keys) Encrypt(plaintext string) (string, error) {
+	if len(keys) == 0 {
+		return "", errors.New("no key found")
+	}
+	aead, err := newAEAD(keys[0])
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.Wrap(err, "generate nonce failed")
+	}
+	data := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + base64.StdEncoding.EncodeToString(data) + suffix, nil
+}
+
+// Decrypt 依次尝试使用各个密钥解密密文 value
+func (keys Keys) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", errors.New("value is not encrypted")
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(strings.TrimPrefix(value, prefix), suffix))
+	if err != nil {
+		return "", errors.Wrap(err, "decode encrypted value failed")
+	}
+	for _, key := range keys {
+		aead, err := newAEAD(key)
+		if err != nil {
+			return "", err
+		}
+		if len(data) < aead.NonceSize() {
+			return "", errors.New("encrypted value is too short")
+		}
+		plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+		if err == nil {
+			return string(plaintext), nil
+		}
+	}
+	return "", errors.New("decrypt failed, no key matches")
+}
+
+// Rotate 将文本（如：配置文件内容）中的所有密文使用 from 解密后，再使用 to 重新加密，返回替换后的文本及密文个数
+func Rotate(text string, from, to Keys) (string, int, error) {
+	var (
+		count int
+		err   error
+	)
+	result := pattern.ReplaceAllStringFunc(text, func(value string) string {
+		if err != nil {
+			return value
+		}
+		var plaintext string
+		if plaintext, err = from.Decrypt(value); err != nil {
+			return value
+		}
+		var rotated string
+		if rotated, err = to.Encrypt(plaintext); err != nil {
+			return value
+		}
+		count++
+		return rotated
+	})
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "rotate the %dth encrypted value failed", count+1)
+	}
+	return result, count, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "new cipher failed")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "new gcm failed")
+	}
+	return aead, nil
+}
@@ -0,0 +1,105 @@
+package secret_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"project/app/pkg/secret"
+	"strings"
+	"testing"
+)
+
+func newKeys(t *testing.T) secret.Keys {
+	key, err := secret.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := secret.ParseKeys(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func TestKeys_EncryptDecrypt(t *testing.T) {
+	a := assert.New(t)
+	keys := newKeys(t)
+
+	value, err := keys.Encrypt("your-value")
+	a.Nil(err)
+	a.True(secret.IsEncrypted(value))
+	a.True(strings.HasPrefix(value, "ENC[AES256_GCM,"))
+	// 每次加密使用随机 nonce
+	other, err := keys.Encrypt("your-value")
+	a.Nil(err)
+	a.NotEqual(value, other)
+
+	plaintext, err := keys.Decrypt(value)
+	a.Nil(err)
+	a.Equal("your-value", plaintext)
+
+	// 密钥不匹配
+	_, err = newKeys(t).Decrypt(value)
+	a.NotNil(err)
+	// 密钥环中任一密钥均可解密
+	plaintext, err = append(newKeys(t), keys...).Decrypt(value)
+	a.Nil(err)
+	a.Equal("your-value", plaintext)
+
+	_, err = keys.Decrypt("your-value")
+	a.NotNil(err)
+	_, err = keys.Decrypt("ENC[AES256_GCM,AAAA]")
+	a.NotNil(err)
+}
+
+func TestParseKeys(t *testing.T) {
+	a := assert.New(t)
+	key1, _ := secret.GenerateKey()
+	key2, _ := secret.GenerateKey()
+	keys, err := secret.ParseKeys(key1 + ", " + key2)
+	a.Nil(err)
+	a.Len(keys, 2)
+
+	_, err = secret.ParseKeys("")
+	a.NotNil(err)
+	_, err = secret.ParseKeys("not base64!")
+	a.NotNil(err)
+	_, err = secret.ParseKeys("c2hvcnQ=")
+	a.NotNil(err)
+}
+
+func TestRotate(t *testing.T) {
+	a := assert.New(t)
+	from, to := newKeys(t), newKeys(t)
+	token, _ := from.Encrypt("token")
+	password, _ := from.Encrypt("password")
+	text := "admin:\n  token: " + token + "\nmysql:\n  user: root\n  password: " + password + "\n"
+
+	rotated, count, err := secret.Rotate(text, from, to)
+	a.Nil(err)
+	a.Equal(2, count)
+	a.NotContains(rotated, token)
+	a.Contains(rotated, "user: root")
+
+	values := regexpValues(rotated)
+	a.Len(values, 2)
+	plaintext, err := to.Decrypt(values[0])
+	a.Nil(err)
+	a.Equal("token", plaintext)
+	plaintext, err = to.Decrypt(values[1])
+	a.Nil(err)
+	a.Equal("password", plaintext)
+
+	// 无法使用 from 解密时不修改
+	_, _, err = secret.Rotate(rotated, from, to)
+	a.NotNil(err)
+}
+
+// regexpValues 返回文本中的所有密文
+func regexpValues(text string) []string {
+	var values []string
+	for _, field := range strings.Fields(text) {
+		if secret.IsEncrypted(field) {
+			values = append(values, field)
+		}
+	}
+	return values
+}
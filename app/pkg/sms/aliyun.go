@@ -1,11 +1,36 @@
 package sms
 
 import (
+	"context"
 	"github.com/aliyun/alibaba-cloud-sdk-go/services/dysmsapi"
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"project/app/pkg/config"
 )
 
+// AliyunConfig 为阿里云短信接口配置，对应配置文件中的 `aliyunLoginSms` 节点
+type AliyunConfig struct {
+	AccessKeyId     string `mapstructure:"accessKeyId" validate:"required"`
+	AccessKeySecret string `mapstructure:"accessKeySecret" validate:"required"`
+	RegionId        string `mapstructure:"regionId" validate:"required"`
+	// 短信签名名称
+	SignName string `mapstructure:"signName" validate:"required"`
+	// 短信模板 code
+	TemplateCode string `mapstructure:"templateCode" validate:"required"`
+}
+
+// NewAliyunConfig 从 viper 中读取阿里云登录短信验证码配置
+func NewAliyunConfig(v *viper.Viper) (*AliyunConfig, error) {
+	cfg := &AliyunConfig{}
+	if err := config.Unmarshal(v, "aliyunLoginSms", cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
 // AliyunLoginSms 负责发送“登录”短信验证码（使用 aliyun sms）
 type AliyunLoginSms struct {
 	accessKeyId     string
@@ -13,22 +38,41 @@ type AliyunLoginSms struct {
 	regionId        string
 	signName        string
 	templateCode    string
+
+	tracer trace.Tracer
 }
 
 var _ Sender = new(AliyunLoginSms)
 
-func NewAliyunLoginSms(v *viper.Viper) *AliyunLoginSms {
+func NewAliyunLoginSms(cfg *AliyunConfig, tracerProvider trace.TracerProvider) *AliyunLoginSms {
 	return &AliyunLoginSms{
-		accessKeyId:     v.GetString("aliyunLoginSms.accessKeyId"),
-		accessKeySecret: v.GetString("aliyunLoginSms.accessKeySecret"),
-		regionId:        v.GetString("aliyunLoginSms.regionId"),
-		signName:        v.GetString("aliyunLoginSms.signName"),
-		templateCode:    v.GetString("aliyunLoginSms.templateCode"),
+		accessKeyId:     cfg.AccessKeyId,
+		accessKeySecret: cfg.AccessKeySecret,
+		regionId:        cfg.RegionId,
+		signName:        cfg.SignName,
+		templateCode:    cfg.TemplateCode,
+		tracer:          tracerProvider.Tracer("project/app/pkg/sms"),
 	}
 }
 
 // Send 发送登录短信验证码
-func (sms *AliyunLoginSms) Send(cellPhoneNumber string, code string, expire int) error {
+//
+// 调用阿里云短信接口的过程记录为 client span（不记录手机号、验证码）。
+func (sms *AliyunLoginSms) Send(ctx context.Context, cellPhoneNumber string, code string, expire int) (err error) {
+	_, span := sms.tracer.Start(ctx, "AliyunLoginSms.Send",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("sms.provider", sms.Provider()),
+			attribute.String("sms.template_code", sms.templateCode),
+		),
+	)
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
 	client, err := dysmsapi.NewClientWithAccessKey(sms.regionId, sms.accessKeyId, sms.accessKeySecret)
 	if err != nil {
 		return errors.Wrap(err, "AliyunLoginSms new client failed")
@@ -40,10 +84,35 @@ func (sms *AliyunLoginSms) Send(cellPhoneNumber string, code string, expire int)
 	request.TemplateCode = sms.templateCode
 	request.TemplateParam = `{"code":"` + code + `"}` // 这里也可以把 expire 配置进模板
 	request.PhoneNumbers = cellPhoneNumber
-	_, err = client.SendSms(request)
+	response, err := client.SendSms(request)
+	if response != nil {
+		span.SetAttributes(
+			attribute.String("sms.request_id", response.RequestId),
+			attribute.String("sms.response_code", response.Code),
+		)
+	}
 	if err != nil {
 		return errors.Wrap(err, "AliyunLoginSms client send sms failed")
 	}
 
 	return nil
 }
+
+// Provider 短信服务商名称
+func (sms *AliyunLoginSms) Provider() string {
+	return "aliyun"
+}
+
+// CheckHealth 检查短信服务配置是否完整、客户端能否创建。
+//
+// 为避免产生费用及受限流影响，不实际调用短信接口。
+func (sms *AliyunLoginSms) CheckHealth(ctx context.Context) error {
+	if sms.accessKeyId == "" || sms.accessKeySecret == "" || sms.regionId == "" ||
+		sms.signName == "" || sms.templateCode == "" {
+		return errors.New("AliyunLoginSms config incomplete")
+	}
+	if _, err := dysmsapi.NewClientWithAccessKey(sms.regionId, sms.accessKeyId, sms.accessKeySecret); err != nil {
+		return errors.Wrap(err, "AliyunLoginSms new client failed")
+	}
+	return nil
+}
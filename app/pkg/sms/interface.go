@@ -1,5 +1,7 @@
 package sms
 
+import "context"
+
 type Sender interface {
 	// 向单个手机号发送短信验证码
 	//
@@ -9,5 +11,7 @@ type Sender interface {
 	//
 	// Note：Send 方法只负责将参数 code、expire 解析到模板中然后返回给用户，其他如
 	// 缓存验证码、验证验证码是否正确等操作一律不要出现在该方法内。
-	Send(cellPhoneNumber string, code string, expire int) error
+	Send(ctx context.Context, cellPhoneNumber string, code string, expire int) error
+	// 短信服务商名称，例：`aliyun`，用于监控指标等
+	Provider() string
 }
@@ -0,0 +1,65 @@
+// 本包用于获取 systemd socket activation 传递的 listener（sd_listen_fds 协议）。
+//
+// systemd 通过环境变量 LISTEN_PID、LISTEN_FDS、LISTEN_FDNAMES 告知进程：从文件描述符 3 开始的 LISTEN_FDS 个
+// 文件描述符为已监听的 socket，LISTEN_FDNAMES 为以冒号分隔的 socket 名称（对应 socket unit 中的 FileDescriptorName）。
+
+package systemd
+
+import (
+	"github.com/pkg/errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenFdsStart 为 systemd 传递的第一个文件描述符
+const listenFdsStart = 3
+
+// Listener 为 systemd 传递的 listener
+type Listener struct {
+	// socket 名称，未设置 FileDescriptorName 时为 systemd 默认值（socket unit 名称）
+	Name string
+	net.Listener
+}
+
+// Listeners 返回 systemd 传递的 listener，并清除相关环境变量，避免子进程误用。
+//
+// 进程不是由 systemd socket activation 启动（未设置环境变量或 LISTEN_PID 与当前进程不符）时返回空。
+func Listeners() ([]Listener, error) {
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	listeners := make([]Listener, 0, n)
+	for i := 0; i < n; i++ {
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+		f := os.NewFile(uintptr(listenFdsStart+i), name)
+		// net.FileListener 复制文件描述符，原文件描述符使用后即可关闭
+		listener, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return nil, errors.Wrapf(err, "systemd socket `%s` (fd %d) is not a listener", name, listenFdsStart+i)
+		}
+		listeners = append(listeners, Listener{Name: name, Listener: listener})
+	}
+	return listeners, nil
+}
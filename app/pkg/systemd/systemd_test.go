@@ -0,0 +1,59 @@
+package systemd
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestListeners_NotActivated(t *testing.T) {
+	a := assert.New(t)
+	a.Nil(os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1)))
+	a.Nil(os.Setenv("LISTEN_FDS", "1"))
+	listeners, err := Listeners()
+	a.Nil(err)
+	a.Empty(listeners)
+	// 环境变量已被清除
+	a.Empty(os.Getenv("LISTEN_FDS"))
+}
+
+// TestListeners 启动子进程模拟 systemd socket activation：通过 ExtraFiles 将 listener 作为 fd 3 传递给子进程，
+// 并借助 sh 的 exec 使 LISTEN_PID 等于子进程 pid。
+func TestListeners(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("systemd socket activation is not supported on windows")
+	}
+	a := assert.New(t)
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	a.Nil(err)
+	defer tcpListener.Close()
+	f, err := tcpListener.(*net.TCPListener).File()
+	a.Nil(err)
+	defer f.Close()
+
+	cmd := exec.Command("sh", "-c", `LISTEN_PID=$$ exec "$0" -test.run=^TestListenersChild$`, os.Args[0])
+	cmd.Env = append(os.Environ(), "SYSTEMD_TEST_CHILD=1", "LISTEN_FDS=1", "LISTEN_FDNAMES=http")
+	cmd.ExtraFiles = []*os.File{f}
+	out, err := cmd.CombinedOutput()
+	a.Nil(err, string(out))
+	a.Contains(string(out), fmt.Sprintf("http %s", tcpListener.Addr()))
+}
+
+func TestListenersChild(t *testing.T) {
+	if os.Getenv("SYSTEMD_TEST_CHILD") != "1" {
+		t.Skip("only run as child process of TestListeners")
+	}
+	listeners, err := Listeners()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listeners) != 1 {
+		t.Fatalf("expected 1 listener, got %d", len(listeners))
+	}
+	fmt.Printf("%s %s\n", listeners[0].Name, listeners[0].Addr())
+}
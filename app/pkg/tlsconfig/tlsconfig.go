@@ -0,0 +1,210 @@
+// 本包用于根据配置生成 http server 使用的 *tls.Config：
+// 支持最低 TLS 版本、加密套件配置，证书文件变更后自动重新加载，以及基于 CA 证书的客户端证书校验（mTLS）。
+
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config 为单个 listener 的 TLS 配置，对应配置文件中 `addr` 节点下的 `tls` 节点
+type Config struct {
+	// 证书文件路径（PEM 格式，可包含证书链）
+	CertFile string `mapstructure:"certFile" validate:"required"`
+	// 私钥文件路径（PEM 格式）
+	KeyFile string `mapstructure:"keyFile" validate:"required"`
+	// 最低 TLS 版本，可选值：1.0、1.1、1.2、1.3，默认 1.2
+	MinVersion string `mapstructure:"minVersion" validate:"omitempty,oneof=1.0 1.1 1.2 1.3"`
+	// 加密套件名称，例：TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256，为空时使用 Go 默认值。仅对 TLS 1.2 及以下版本生效
+	CipherSuites []string `mapstructure:"cipherSuites"`
+	// 客户端证书 CA 文件路径（PEM 格式，可包含多个 CA 证书），为空时不校验客户端证书
+	ClientCAFile string `mapstructure:"clientCAFile"`
+	// 客户端证书校验方式，可选值：require（必须提供合法的客户端证书，默认）、optional（提供时校验）
+	ClientAuth string `mapstructure:"clientAuth" validate:"omitempty,oneof=require optional"`
+	// 检查证书文件是否变更的最小间隔，默认 10s
+	ReloadInterval time.Duration `mapstructure:"reloadInterval" validate:"gte=0"`
+}
+
+// versions 为 MinVersion 可选值
+var versions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// clientAuthTypes 为 ClientAuth 可选值
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"require":  tls.RequireAndVerifyClientCert,
+	"optional": tls.VerifyClientCertIfGiven,
+}
+
+// New 根据配置生成 *tls.Config。
+//
+// 每次 TLS 握手时，若距上次检查已超过 ReloadInterval，将检查证书、私钥、客户端 CA 文件的修改时间，
+// 文件变更后重新加载；重新加载失败时记录错误日志，并继续使用原证书。
+func New(cfg *Config, zapLogger *zap.Logger) (*tls.Config, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("tls certFile and keyFile are required")
+	}
+
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	if cfg.MinVersion != "" {
+		version, ok := versions[cfg.MinVersion]
+		if !ok {
+			return nil, errors.Errorf("unsupported tls minVersion `%s`", cfg.MinVersion)
+		}
+		base.MinVersion = version
+	}
+	for _, name := range cfg.CipherSuites {
+		id, err := cipherSuite(name)
+		if err != nil {
+			return nil, err
+		}
+		base.CipherSuites = append(base.CipherSuites, id)
+	}
+	if cfg.ClientCAFile != "" {
+		clientAuth := cfg.ClientAuth
+		if clientAuth == "" {
+			clientAuth = "require"
+		}
+		authType, ok := clientAuthTypes[clientAuth]
+		if !ok {
+			return nil, errors.Errorf("unsupported tls clientAuth `%s`", cfg.ClientAuth)
+		}
+		base.ClientAuth = authType
+	} else if cfg.ClientAuth != "" {
+		return nil, errors.New("tls clientAuth requires clientCAFile")
+	}
+
+	r := &reloader{
+		cfg:       cfg,
+		base:      base,
+		interval:  cfg.ReloadInterval,
+		zapLogger: zapLogger.Named("tls"),
+	}
+	if r.interval <= 0 {
+		r.interval = 10 * time.Second
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		// tls.NewListener 要求 Certificates、GetCertificate、GetConfigForClient 至少设置其一，
+		// 实际握手使用 GetConfigForClient 返回的配置
+		GetConfigForClient: r.getConfigForClient,
+	}, nil
+}
+
+// cipherSuite 根据名称查找安全的加密套件
+func cipherSuite(name string) (uint16, error) {
+	for _, suite := range tls.CipherSuites() {
+		if strings.EqualFold(suite.Name, name) {
+			return suite.ID, nil
+		}
+	}
+	return 0, errors.Errorf("unsupported or insecure tls cipher suite `%s`", name)
+}
+
+// reloader 负责在证书文件变更后重新加载证书
+type reloader struct {
+	cfg       *Config
+	base      *tls.Config
+	interval  time.Duration
+	zapLogger *zap.Logger
+
+	mu       sync.Mutex
+	current  *tls.Config
+	modTimes map[string]time.Time // 已加载文件的修改时间
+	checked  time.Time            // 最近一次检查文件修改时间的时间
+}
+
+// getConfigForClient 返回当前生效的 *tls.Config，必要时重新加载证书
+func (r *reloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(r.checked) >= r.interval {
+		r.checked = now
+		if r.changed() {
+			if err := r.load(); err != nil {
+				r.zapLogger.Error("tls certificate reload failed", zap.Error(err), zap.String("cert_file", r.cfg.CertFile))
+			} else {
+				r.zapLogger.Info("tls certificate reloaded", zap.String("cert_file", r.cfg.CertFile))
+			}
+		}
+	}
+	return r.current, nil
+}
+
+// files 返回需要监测变更的文件
+func (r *reloader) files() []string {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+	return files
+}
+
+// changed 判断证书文件的修改时间是否发生变化
+func (r *reloader) changed() bool {
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			// 文件暂时不可读（如正在被替换）时，等待下次检查
+			continue
+		}
+		if !info.ModTime().Equal(r.modTimes[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+// load 加载证书、私钥及客户端 CA，成功后替换当前生效的 *tls.Config
+func (r *reloader) load() error {
+	modTimes := map[string]time.Time{}
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return errors.Wrapf(err, "stat `%s` failed", file)
+		}
+		modTimes[file] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return errors.Wrap(err, "load tls certificate failed")
+	}
+	config := r.base.Clone()
+	config.Certificates = []tls.Certificate{cert}
+
+	if r.cfg.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return errors.Wrap(err, "read tls client CA file failed")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.Errorf("no valid certificate found in tls client CA file `%s`", r.cfg.ClientCAFile)
+		}
+		config.ClientCAs = pool
+	}
+
+	r.current = config
+	r.modTimes = modTimes
+	return nil
+}
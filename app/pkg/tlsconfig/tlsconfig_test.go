@@ -0,0 +1,104 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"project/app/test/helper"
+	"testing"
+	"time"
+)
+
+func TestNew_Invalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tlsconfig")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	ca := helper.NewCert(t, "ca", nil)
+	helper.NewCert(t, "server", ca).WriteFiles(t, certFile, keyFile)
+
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"missing key", Config{CertFile: certFile}},
+		{"missing file", Config{CertFile: certFile, KeyFile: filepath.Join(dir, "missing.pem")}},
+		{"min version", Config{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.4"}},
+		{"insecure cipher suite", Config{CertFile: certFile, KeyFile: keyFile, CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}}},
+		{"client auth without CA", Config{CertFile: certFile, KeyFile: keyFile, ClientAuth: "require"}},
+		{"client auth", Config{CertFile: certFile, KeyFile: keyFile, ClientCAFile: certFile, ClientAuth: "any"}},
+		{"invalid CA", Config{CertFile: certFile, KeyFile: keyFile, ClientCAFile: keyFile}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(&tt.cfg, zap.NewNop())
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "tlsconfig")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+	certFile, keyFile, caFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "ca.pem")
+	ca := helper.NewCert(t, "ca", nil)
+	helper.NewCert(t, "server", ca).WriteFiles(t, certFile, keyFile)
+	a.Nil(ioutil.WriteFile(caFile, ca.CertPEM, 0600))
+
+	config, err := New(&Config{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		MinVersion:   "1.2",
+		CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+		ClientCAFile: caFile,
+		ClientAuth:   "optional",
+	}, zap.NewNop())
+	a.Nil(err)
+	current, err := config.GetConfigForClient(&tls.ClientHelloInfo{})
+	a.Nil(err)
+	a.Equal(uint16(tls.VersionTLS12), current.MinVersion)
+	a.Equal([]uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, current.CipherSuites)
+	a.Equal(tls.VerifyClientCertIfGiven, current.ClientAuth)
+	a.NotNil(current.ClientCAs)
+	a.Len(current.Certificates, 1)
+}
+
+func TestNew_Reload(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "tlsconfig")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	ca := helper.NewCert(t, "ca", nil)
+	helper.NewCert(t, "server-1", ca).WriteFiles(t, certFile, keyFile)
+
+	config, err := New(&Config{CertFile: certFile, KeyFile: keyFile, ReloadInterval: time.Nanosecond}, zap.NewNop())
+	a.Nil(err)
+	commonName := func() string {
+		current, err := config.GetConfigForClient(&tls.ClientHelloInfo{})
+		a.Nil(err)
+		cert, err := x509.ParseCertificate(current.Certificates[0].Certificate[0])
+		a.Nil(err)
+		return cert.Subject.CommonName
+	}
+	a.Equal("server-1", commonName())
+
+	// 证书文件变更后重新加载
+	helper.NewCert(t, "server-2", ca).WriteFiles(t, certFile, keyFile)
+	future := time.Now().Add(time.Minute)
+	a.Nil(os.Chtimes(certFile, future, future))
+	a.Nil(os.Chtimes(keyFile, future, future))
+	a.Equal("server-2", commonName())
+
+	// 重新加载失败时继续使用原证书
+	a.Nil(ioutil.WriteFile(keyFile, []byte("invalid"), 0600))
+	future = future.Add(time.Minute)
+	a.Nil(os.Chtimes(keyFile, future, future))
+	a.Equal("server-2", commonName())
+}
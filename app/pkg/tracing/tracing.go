@@ -0,0 +1,171 @@
+// 本包用于分布式链路追踪（OpenTelemetry）：根据配置实例化 trace.TracerProvider 及导出器，
+// 并定义跨进程传递追踪上下文使用的传播器（W3C traceparent、baggage）。
+
+package tracing
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"io"
+	"os"
+	"project/app/pkg/buildinfo"
+	"project/app/pkg/config"
+	"time"
+)
+
+// 导出器类型
+const (
+	// 不采集链路数据
+	ExporterNone = "none"
+	// 以 JSON 格式输出到标准输出，每行一个 span
+	ExporterStdout = "stdout"
+	// 以 JSON 格式输出到文件，每行一个 span，可离线分析
+	ExporterFile = "file"
+	// 以 OTLP/HTTP 协议导出到 OpenTelemetry Collector 或兼容的后端（如：Jaeger、Tempo）
+	ExporterOTLP = "otlp"
+)
+
+// Config 为链路追踪配置，对应配置文件中的 `tracing` 节点
+type Config struct {
+	// 导出器：none、stdout、file、otlp
+	Exporter string `mapstructure:"exporter" validate:"oneof=none stdout file otlp"`
+	// 服务名称，即 span 的 service.name 资源属性
+	ServiceName string `mapstructure:"serviceName" validate:"required"`
+	// 采样率（0~1），仅对无上游采样决定的请求生效；上游已决定采样与否时，遵循上游决定
+	SampleRatio float64 `mapstructure:"sampleRatio" validate:"gte=0,lte=1"`
+	// exporter 为 file 时的输出文件路径
+	File string `mapstructure:"file"`
+	// exporter 为 otlp 时的配置
+	OTLP OTLPConfig `mapstructure:"otlp"`
+}
+
+// OTLPConfig 为 OTLP/HTTP 导出器配置
+type OTLPConfig struct {
+	// 接收端地址（host:port），例：localhost:4318
+	Endpoint string `mapstructure:"endpoint"`
+	// 接收端 URL 路径，为空时使用默认值 /v1/traces
+	URLPath string `mapstructure:"urlPath"`
+	// 是否使用明文 http
+	Insecure bool `mapstructure:"insecure"`
+	// 附加的 http header，如：鉴权令牌
+	Headers map[string]string `mapstructure:"headers"`
+	// 单次导出的超时时间
+	Timeout time.Duration `mapstructure:"timeout" validate:"gt=0"`
+}
+
+// NewConfig 从 viper 中读取链路追踪配置
+func NewConfig(v *viper.Viper) (*Config, error) {
+	cfg := &Config{
+		Exporter:    ExporterNone,
+		ServiceName: "app",
+		SampleRatio: 1,
+		OTLP:        OTLPConfig{Timeout: 10 * time.Second},
+	}
+	if err := config.Unmarshal(v, "tracing", cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Exporter == ExporterFile && cfg.File == "" {
+		return nil, errors.New("tracing.file is required when tracing.exporter is file")
+	}
+	if cfg.Exporter == ExporterOTLP && cfg.OTLP.Endpoint == "" {
+		return nil, errors.New("tracing.otlp.endpoint is required when tracing.exporter is otlp")
+	}
+	return cfg, nil
+}
+
+// Propagator 为跨进程传递追踪上下文使用的传播器：W3C Trace Context（traceparent、tracestate）及 W3C Baggage
+var Propagator propagation.TextMapPropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
+// shutdownTimeout 为关闭 TracerProvider 时，导出剩余 span 的最长时间
+const shutdownTimeout = 5 * time.Second
+
+// NewTracerProvider 根据配置实例化 trace.TracerProvider，exporter 为 none 时返回不采集数据的 TracerProvider。
+//
+// 返回的 cleanup 函数负责导出缓冲中的剩余 span 并关闭导出器。导出失败等错误记录在日志中，不影响请求处理。
+func NewTracerProvider(cfg *Config, zapLogger *zap.Logger) (provider trace.TracerProvider, cleanup func(), err error) {
+	if cfg.Exporter == ExporterNone {
+		return trace.NewNoopTracerProvider(), func() {}, nil
+	}
+
+	var (
+		exporter sdktrace.SpanExporter
+		closer   io.Closer
+	)
+	switch cfg.Exporter {
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterFile:
+		var file *os.File
+		if file, err = os.OpenFile(cfg.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+			return nil, nil, errors.Wrapf(err, "open tracing file `%s` failed", cfg.File)
+		}
+		closer = file
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+	case ExporterOTLP:
+		opts := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(cfg.OTLP.Endpoint),
+			otlptracehttp.WithHeaders(cfg.OTLP.Headers),
+			otlptracehttp.WithTimeout(cfg.OTLP.Timeout),
+		}
+		if cfg.OTLP.URLPath != "" {
+			opts = append(opts, otlptracehttp.WithURLPath(cfg.OTLP.URLPath))
+		}
+		if cfg.OTLP.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		// OTLP/HTTP 导出器启动时不连接接收端，接收端不可用时仅导出失败
+		exporter, err = otlptracehttp.New(context.Background(), opts...)
+	}
+	if err != nil {
+		if closer != nil {
+			_ = closer.Close()
+		}
+		return nil, nil, errors.Wrapf(err, "create tracing exporter `%s` failed", cfg.Exporter)
+	}
+
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		zapLogger.Warn("tracing error", zap.Error(err))
+	}))
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(cfg.ServiceName),
+			semconv.ServiceVersionKey.String(buildinfo.Get().Version),
+		)),
+	)
+	cleanup = func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := tracerProvider.Shutdown(ctx); err != nil {
+			zapLogger.Warn("tracing shutdown failed", zap.Error(err))
+		}
+		if closer != nil {
+			_ = closer.Close()
+		}
+	}
+	return tracerProvider, cleanup, nil
+}
+
+// TraceId 返回 ctx 中的 trace id（十六进制），不存在有效的追踪上下文时返回空字符串
+func TraceId(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewConfig(t *testing.T) {
+	a := assert.New(t)
+	cfg, err := NewConfig(viper.New())
+	a.Nil(err)
+	a.Equal(ExporterNone, cfg.Exporter)
+
+	invalid := []map[string]interface{}{
+		{"exporter": "jaeger"},
+		{"exporter": ExporterFile},
+		{"exporter": ExporterOTLP},
+		{"sampleRatio": 1.5},
+	}
+	for _, item := range invalid {
+		v := viper.New()
+		v.Set("tracing", item)
+		_, err = NewConfig(v)
+		a.NotNil(err, "%v", item)
+	}
+}
+
+func TestNewTracerProvider_None(t *testing.T) {
+	a := assert.New(t)
+	provider, cleanup, err := NewTracerProvider(&Config{Exporter: ExporterNone}, zap.NewNop())
+	a.Nil(err)
+	defer cleanup()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "span")
+	span.End()
+	a.Empty(TraceId(ctx))
+}
+
+func TestNewTracerProvider_File(t *testing.T) {
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "tracing")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "trace.log")
+
+	provider, cleanup, err := NewTracerProvider(
+		&Config{Exporter: ExporterFile, File: file, ServiceName: "sample", SampleRatio: 1}, zap.NewNop())
+	a.Nil(err)
+	ctx, span := provider.Tracer("test").Start(context.Background(), "parent")
+	_, child := provider.Tracer("test").Start(ctx, "child")
+	child.End()
+	span.End()
+	traceId := TraceId(ctx)
+	a.Len(traceId, 32)
+	// cleanup 导出缓冲中的剩余 span
+	cleanup()
+
+	data, err := ioutil.ReadFile(file)
+	a.Nil(err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	a.Len(lines, 2)
+	var exported struct {
+		Name        string
+		SpanContext struct{ TraceID string }
+	}
+	a.Nil(json.Unmarshal([]byte(lines[1]), &exported))
+	a.Equal("parent", exported.Name)
+	a.Equal(traceId, exported.SpanContext.TraceID)
+}
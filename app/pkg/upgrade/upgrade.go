@@ -0,0 +1,178 @@
+// 本包用于不停机升级：当前进程启动新进程（通常为替换后的新版本二进制文件），并将已监听的 listener 文件描述符
+// 传递给新进程，待新进程就绪后当前进程再优雅关闭，升级期间不会拒绝任何连接。
+//
+// listener 文件描述符从 3 开始依次传递，监听地址通过环境变量 APP_UPGRADE_LISTENERS（JSON 数组）传递，
+// 新进程通过环境变量 APP_UPGRADE_READY_FD 指定的管道通知当前进程已就绪。
+
+package upgrade
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// envListeners 为传递的 listener 对应的监听地址
+	envListeners = "APP_UPGRADE_LISTENERS"
+	// envReadyFd 为新进程通知就绪的管道文件描述符
+	envReadyFd = "APP_UPGRADE_READY_FD"
+	// listenFdsStart 为传递的第一个文件描述符
+	listenFdsStart = 3
+)
+
+// Listener 为在新旧进程间传递的 listener
+type Listener struct {
+	// 配置中的监听地址，新进程据此匹配配置文件中的监听地址
+	Addr string
+	// 原始 listener（非 TLS listener），须为 *net.TCPListener 或 *net.UnixListener
+	net.Listener
+}
+
+// filer 为可获取文件描述符的 listener
+type filer interface {
+	File() (*os.File, error)
+}
+
+// Inherited 返回父进程通过 Upgrade 传递的 listener，并清除相关环境变量，避免再传递给子进程。
+//
+// 当前进程不是由 Upgrade 启动时返回空。
+func Inherited() ([]Listener, error) {
+	value := os.Getenv(envListeners)
+	_ = os.Unsetenv(envListeners)
+	if value == "" {
+		return nil, nil
+	}
+	var addresses []string
+	if err := json.Unmarshal([]byte(value), &addresses); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s", envListeners)
+	}
+
+	listeners := make([]Listener, 0, len(addresses))
+	for i, addr := range addresses {
+		f := os.NewFile(uintptr(listenFdsStart+i), addr)
+		// net.FileListener 复制文件描述符，原文件描述符使用后即可关闭
+		listener, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return nil, errors.Wrapf(err, "inherited fd %d for `%s` is not a listener", listenFdsStart+i, addr)
+		}
+		listeners = append(listeners, Listener{Addr: addr, Listener: listener})
+	}
+	return listeners, nil
+}
+
+// Ready 通知父进程当前进程已就绪，父进程随后优雅关闭。当前进程不是由 Upgrade 启动时不做任何处理。
+func Ready() error {
+	value := os.Getenv(envReadyFd)
+	_ = os.Unsetenv(envReadyFd)
+	if value == "" {
+		return nil
+	}
+	fd, err := strconv.Atoi(value)
+	if err != nil {
+		return errors.Wrapf(err, "invalid %s", envReadyFd)
+	}
+	f := os.NewFile(uintptr(fd), "upgrade-ready")
+	defer f.Close()
+	if _, err := f.Write([]byte{1}); err != nil {
+		return errors.Wrap(err, "notify parent process failed")
+	}
+	return nil
+}
+
+// Upgrade 使用当前可执行文件路径及启动参数启动新进程，传递 listener 文件描述符，并在 timeout 内等待新进程调用 Ready()。
+//
+// 新进程启动失败、未就绪即退出或超时未就绪时，终止新进程并返回错误，当前进程可继续提供服务。
+// 返回新进程的 pid。
+func Upgrade(listeners []Listener, timeout time.Duration) (int, error) {
+	// 可执行文件被替换后，os.Executable 仍返回原路径，即新版本二进制文件
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, errors.Wrap(err, "get executable path failed")
+	}
+
+	files := make([]*os.File, 0, len(listeners)+1)
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	addresses := make([]string, 0, len(listeners))
+	for _, l := range listeners {
+		fl, ok := l.Listener.(filer)
+		if !ok {
+			return 0, errors.Errorf("listener `%s` (%T) can not be passed to new process", l.Addr, l.Listener)
+		}
+		f, err := fl.File()
+		if err != nil {
+			return 0, errors.Wrapf(err, "get file of listener `%s` failed", l.Addr)
+		}
+		files = append(files, f)
+		addresses = append(addresses, l.Addr)
+	}
+	encoded, err := json.Marshal(addresses)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return 0, errors.Wrap(err, "create ready pipe failed")
+	}
+	defer r.Close()
+	files = append(files, w)
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(
+		environ(),
+		envListeners+"="+string(encoded),
+		envReadyFd+"="+strconv.Itoa(listenFdsStart+len(files)-1),
+	)
+	if err := cmd.Start(); err != nil {
+		return 0, errors.Wrap(err, "start new process failed")
+	}
+	// 回收新进程，避免新进程先于当前进程退出时成为僵尸进程
+	go func() { _ = cmd.Wait() }()
+	// 关闭当前进程持有的管道写端，新进程退出时读端即可读到 EOF
+	_ = w.Close()
+
+	readyCh := make(chan error, 1)
+	go func() {
+		_, err := r.Read(make([]byte, 1))
+		readyCh <- err
+	}()
+	select {
+	case err := <-readyCh:
+		if err != nil {
+			_ = cmd.Process.Kill()
+			return 0, errors.Wrap(err, "new process exited before ready")
+		}
+	case <-time.After(timeout):
+		_ = cmd.Process.Kill()
+		return 0, errors.Errorf("new process not ready in %s", timeout)
+	}
+	return cmd.Process.Pid, nil
+}
+
+// environ 返回当前进程的环境变量，不包含本包使用的环境变量
+func environ() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, envListeners+"=") || strings.HasPrefix(kv, envReadyFd+"=") {
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
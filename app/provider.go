@@ -0,0 +1,119 @@
+package app
+
+import (
+	"github.com/google/wire"
+	"project/app/dao"
+	"project/app/handler"
+	"project/app/pkg/cache"
+	"project/app/pkg/config"
+	"project/app/pkg/db"
+	"project/app/pkg/health"
+	"project/app/pkg/logger"
+	"project/app/pkg/metrics"
+	"project/app/pkg/pagination"
+	"project/app/pkg/panicreport"
+	"project/app/pkg/redact"
+	"project/app/pkg/sms"
+	"project/app/pkg/tracing"
+	"project/app/service"
+)
+
+// ProviderSet 为 app、console 等可执行文件共用的 provider 集合
+var ProviderSet = wire.NewSet(CoreSet, DatabaseSet)
+
+// DatabaseSet 为数据库连接的 provider 集合：检查连接，并按配置自动执行迁移
+var DatabaseSet = wire.NewSet(
+	db.NewConfig,
+	db.NewDB,
+	dao.NewMigrations,
+)
+
+// CoreSet 为 ProviderSet 中除数据库连接以外的 provider 集合，
+// 供不访问数据库的命令搭配 db.NewUnconnectedDB 使用（如：console routes）
+var CoreSet = wire.NewSet(
+	// 公共 provider
+	NewViper,
+	NewConfigSchema,
+	config.NewIsDebug,
+	cache.NewGoCache,
+
+	// app
+	NewApp,
+	NewListenerConfigs,
+	NewAdminListenerConfigs,
+	NewServerConfig,
+	NewHealthRegistry,
+	health.NewConfig,
+
+	// RequestIdMiddleware
+	wire.Value(&handler.RequestIdMiddleware{}),
+	wire.Value(&handler.ClientCertMiddleware{}),
+
+	// TracingMiddleware
+	handler.NewTracingMiddleware,
+	tracing.NewConfig,
+	tracing.NewTracerProvider,
+
+	// LoggerMiddleware
+	handler.NewLoggerMiddleware,
+	handler.NewBodyCaptureConfig,
+	logger.NewConfig,
+	logger.NewLevelController,
+	logger.NewZapLogger,
+	redact.NewRedactor,
+
+	// MetricsMiddleware
+	handler.NewMetricsMiddleware,
+	metrics.NewConfig,
+	metrics.NewRegistry,
+	metrics.NewHttpMetrics,
+	metrics.NewSmsMetrics,
+
+	// RecoveryMiddleware
+	handler.NewRecoveryMiddleware,
+	panicreport.NewConfig,
+	panicreport.NewRecorder,
+	panicreport.NewLogNotifier,
+	wire.Bind(new(panicreport.Notifier), new(*panicreport.LogNotifier)),
+
+	// AdminAuthMiddleware
+	handler.NewAdminAuthMiddleware,
+	handler.NewAdminConfig,
+
+	// LogLevelCtrl
+	handler.NewLogLevelCtrl,
+
+	// PanicReportCtrl
+	handler.NewPanicReportCtrl,
+
+	// HealthCtrl
+	handler.NewHealthCtrl,
+
+	// DebugCtrl
+	handler.NewDebugCtrl,
+
+	// ConfigCtrl
+	handler.NewConfigCtrl,
+	NewConfigReloader,
+	config.NewReloadConfig,
+
+	// MetricsCtrl
+	handler.NewMetricsCtrl,
+
+	// LoginSmsCtrl
+	handler.NewLoginSmsCtrl,
+	service.NewLoginSmsService,
+	service.NewLoginSmsConfig,
+	wire.Bind(new(service.ISms), new(*service.LoginSmsService)),
+	sms.NewAliyunLoginSms,
+	sms.NewAliyunConfig,
+	wire.Bind(new(sms.Sender), new(*sms.AliyunLoginSms)),
+
+	// UserCtrl
+	handler.NewUserCtrl,
+	service.NewUserService,
+	wire.Bind(new(service.IUser), new(*service.UserService)),
+	dao.NewUserDao,
+	pagination.NewConfig,
+	pagination.NewPaginator,
+)
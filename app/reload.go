@@ -0,0 +1,61 @@
+package app
+
+import (
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"project/app/pkg/config"
+	"project/app/pkg/logger"
+	"project/app/service"
+)
+
+// NewConfigReloader 实例化配置热加载器，订阅支持热加载的配置，并开始检查配置文件变更。
+//
+// 支持热加载的配置：日志级别（log.level）、登录短信验证码发送频率限制（loginSms.rateLimit），其它配置项的变更须重启后生效。
+func NewConfigReloader(
+	cfg *config.ReloadConfig,
+	v *viper.Viper,
+	overrides config.Overrides,
+	configFiles []config.FilePath,
+	schema config.Schema,
+	logConfig *logger.Config,
+	levelController *logger.LevelController,
+	loginSmsService *service.LoginSmsService,
+	zapLogger *zap.Logger,
+) (*config.Reloader, func(), error) {
+	reloader, err := config.NewReloader(v, overrides, configFiles, schema, zapLogger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 日志级别：仅在配置的级别变更时生效，以免覆盖通过管理接口调整的级别
+	level := logConfig.Level
+	reloader.Subscribe(func(v *viper.Viper) (func(), error) {
+		cfg, err := logger.NewConfig(config.NewIsDebug(v), v)
+		if err != nil {
+			return nil, err
+		}
+		newLevel, err := logger.ParseLevel(cfg.Level)
+		if err != nil {
+			return nil, err
+		}
+		return func() {
+			if cfg.Level != level {
+				levelController.SetConfigLevel(newLevel)
+				level = cfg.Level
+			}
+		}, nil
+	})
+	// 登录短信验证码发送频率限制
+	reloader.Subscribe(func(v *viper.Viper) (func(), error) {
+		cfg, err := service.NewLoginSmsConfig(v)
+		if err != nil {
+			return nil, err
+		}
+		return func() { loginSmsService.SetConfig(cfg) }, nil
+	})
+
+	if cfg.Interval > 0 {
+		reloader.Watch(cfg.Interval)
+	}
+	return reloader, reloader.Close, nil
+}
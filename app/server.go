@@ -0,0 +1,240 @@
+package app
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"project/app/pkg/config"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ServerConfig 为 http.Server 配置，对应配置文件中的 `server` 节点
+type ServerConfig struct {
+	// 读取整个请求（含请求体）的超时时间
+	ReadTimeout time.Duration `mapstructure:"readTimeout" validate:"gte=0"`
+	// 读取请求头的超时时间
+	ReadHeaderTimeout time.Duration `mapstructure:"readHeaderTimeout" validate:"gte=0"`
+	// 写出响应的超时时间
+	WriteTimeout time.Duration `mapstructure:"writeTimeout" validate:"gte=0"`
+	// keep-alive 连接的空闲超时时间
+	IdleTimeout time.Duration `mapstructure:"idleTimeout" validate:"gte=0"`
+	// 请求头最大字节数
+	MaxHeaderBytes int `mapstructure:"maxHeaderBytes" validate:"gte=0"`
+	// 优雅关闭开始后，就绪检查（/readyz）即返回未就绪，等待 ShutdownDelay 后再停止接受新连接，使负载均衡器有时间摘除实例
+	ShutdownDelay time.Duration `mapstructure:"shutdownDelay" validate:"gte=0"`
+	// 优雅关闭时，等待进行中的请求处理完毕的最长时间，超时后强制关闭连接
+	ShutdownTimeout time.Duration `mapstructure:"shutdownTimeout" validate:"gt=0"`
+	// 不停机升级时，等待新进程就绪的最长时间，超时后终止新进程，当前进程继续提供服务
+	UpgradeTimeout time.Duration `mapstructure:"upgradeTimeout" validate:"gt=0"`
+}
+
+// NewServerConfig 从 viper 中读取 http.Server 配置
+func NewServerConfig(v *viper.Viper) (*ServerConfig, error) {
+	cfg := &ServerConfig{
+		ReadTimeout:       10 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		MaxHeaderBytes:    http.DefaultMaxHeaderBytes,
+		ShutdownTimeout:   30 * time.Second,
+		UpgradeTimeout:    30 * time.Second,
+	}
+	if err := config.Unmarshal(v, "server", cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// signalContext 返回一个在收到指定信号时结束的 context.Context
+func signalContext(signals ...os.Signal) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	go func() {
+		select {
+		case <-ch:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(ch)
+		cancel()
+	}
+}
+
+// serve 在所有 listener 上启动 http.Server，直到 ctx 结束或任一 http.Server 出错，然后优雅关闭所有 http.Server：
+// 标记为未就绪并等待 ShutdownDelay，停止接受新连接，等待已建立连接上的请求开始处理，再等待进行中的请求处理完毕，超过 ShutdownTimeout 后强制关闭连接。
+//
+// 管理接口监听地址（listener.admin）使用 adminHandler，且不设置 WriteTimeout，以支持耗时较长的 pprof 采样。
+func (app *App) serve(ctx context.Context, handler, adminHandler http.Handler, listeners []listener) error {
+	tracker := newConnTracker()
+	handler = tracker.handler(handler)
+	if adminHandler != nil {
+		adminHandler = tracker.handler(adminHandler)
+	}
+
+	servers := make([]*http.Server, 0, len(listeners))
+	errCh := make(chan error, len(listeners))
+	stopping := make(chan struct{})
+	var serveWg sync.WaitGroup
+	for _, l := range listeners {
+		server := &http.Server{
+			Handler:           handler,
+			ReadTimeout:       app.serverConfig.ReadTimeout,
+			ReadHeaderTimeout: app.serverConfig.ReadHeaderTimeout,
+			WriteTimeout:      app.serverConfig.WriteTimeout,
+			IdleTimeout:       app.serverConfig.IdleTimeout,
+			MaxHeaderBytes:    app.serverConfig.MaxHeaderBytes,
+			ConnState:         tracker.connState,
+			ConnContext:       tracker.connContext,
+		}
+		if l.admin {
+			server.Handler = adminHandler
+			server.WriteTimeout = 0
+		}
+		servers = append(servers, server)
+
+		app.zapLogger.Info("http server listening", zap.String("addr", l.Addr().String()), zap.Bool("admin", l.admin))
+		serveWg.Add(1)
+		go func(l listener) {
+			defer serveWg.Done()
+			err := server.Serve(l)
+			select {
+			case <-stopping:
+				// 关闭过程中主动关闭 listener 导致的错误
+				return
+			default:
+			}
+			if err != nil && err != http.ErrServerClosed {
+				errCh <- errors.Wrapf(err, "http server on `%s` failed", l.Addr())
+			}
+		}(l)
+	}
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+		app.zapLogger.Info("http server shutting down", zap.Duration("timeout", app.serverConfig.ShutdownTimeout))
+	case serveErr = <-errCh:
+		app.zapLogger.Error("http server shutting down", zap.Error(serveErr))
+	}
+
+	// 不停机升级时，新进程已在同一 listener 上就绪，无需等待负载均衡器摘除实例
+	if atomic.LoadInt32(&app.upgraded) == 0 {
+		app.healthRegistry.SetShuttingDown()
+		if app.serverConfig.ShutdownDelay > 0 {
+			time.Sleep(app.serverConfig.ShutdownDelay)
+		}
+	}
+
+	// 先停止接受新连接，并等待已建立连接上的首个请求开始处理：
+	// http.Server.Shutdown 开始后，已建立连接上新读取到的请求将被直接丢弃（客户端收到 EOF）
+	close(stopping)
+	for _, l := range listeners {
+		_ = l.Close()
+	}
+	serveWg.Wait()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), app.serverConfig.ShutdownTimeout)
+	defer cancel()
+	tracker.wait(shutdownCtx)
+	var (
+		wg          sync.WaitGroup
+		mu          sync.Mutex
+		shutdownErr error
+	)
+	for _, server := range servers {
+		wg.Add(1)
+		go func(server *http.Server) {
+			defer wg.Done()
+			if err := server.Shutdown(shutdownCtx); err != nil {
+				// 超时后强制关闭剩余连接
+				_ = server.Close()
+				mu.Lock()
+				shutdownErr = errors.Wrap(err, "http server shutdown failed")
+				mu.Unlock()
+			}
+		}(server)
+	}
+	wg.Wait()
+	app.zapLogger.Info("http server stopped")
+
+	if serveErr != nil {
+		return serveErr
+	}
+	return shutdownErr
+}
+
+// connTracker 记录已建立但尚未开始处理请求的连接
+type connTracker struct {
+	mu      sync.Mutex
+	pending map[net.Conn]struct{}
+}
+
+// connContextKey 为 context.Context 中连接对应的 key 类型
+type connContextKey struct{}
+
+func newConnTracker() *connTracker {
+	return &connTracker{pending: map[net.Conn]struct{}{}}
+}
+
+// connState 为 http.Server.ConnState 回调：记录新建立的连接，连接关闭后移除。
+//
+// 连接变为 http.StateActive 时请求尚未开始处理，此时开始 Shutdown 仍会丢弃该请求，因此在 handler 中移除。
+func (t *connTracker) connState(conn net.Conn, state http.ConnState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch state {
+	case http.StateNew:
+		t.pending[conn] = struct{}{}
+	case http.StateHijacked, http.StateClosed:
+		delete(t.pending, conn)
+	}
+}
+
+// connContext 为 http.Server.ConnContext 回调：将连接存入 context.Context，供 handler 识别请求所属连接
+func (t *connTracker) connContext(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, conn)
+}
+
+// handler 包装 http.Handler：请求开始处理时，将所属连接移出待处理列表
+func (t *connTracker) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conn, ok := r.Context().Value(connContextKey{}).(net.Conn); ok {
+			t.mu.Lock()
+			delete(t.pending, conn)
+			t.mu.Unlock()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// wait 等待所有已建立连接上的首个请求开始处理（或连接关闭），直到 ctx 结束
+func (t *connTracker) wait(ctx context.Context) {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		t.mu.Lock()
+		n := len(t.pending)
+		t.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// shutdownSignals 为触发优雅关闭的信号
+var shutdownSignals = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
@@ -0,0 +1,173 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"project/app/pkg/health"
+	"testing"
+	"time"
+)
+
+func TestApp_Serve_GracefulShutdown(t *testing.T) {
+	a := assert.New(t)
+	app := newTestApp(&ServerConfig{ShutdownTimeout: 5 * time.Second})
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write([]byte("done"))
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	a.Nil(err)
+	url := "http://" + l.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- app.serve(ctx, handler, nil, []listener{{Listener: l}}) }()
+
+	respCh := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			respCh <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		respCh <- string(body)
+	}()
+
+	// 请求处理过程中开始优雅关闭，进行中的请求应正常完成
+	<-started
+	cancel()
+	a.Equal("done", <-respCh)
+	a.Nil(<-serveErr)
+
+	_, err = http.Get(url)
+	a.NotNil(err)
+}
+
+func TestApp_Serve_PendingConnection(t *testing.T) {
+	a := assert.New(t)
+	app := newTestApp(&ServerConfig{ShutdownTimeout: 5 * time.Second})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("done"))
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	a.Nil(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- app.serve(ctx, handler, nil, []listener{{Listener: l}}) }()
+
+	// 连接已建立、请求尚未发送时开始优雅关闭，随后发送的请求仍应正常处理
+	conn, err := net.Dial("tcp", l.Addr().String())
+	a.Nil(err)
+	defer conn.Close()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: test\r\n\r\n"))
+	a.Nil(err)
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if a.Nil(err) {
+		body, _ := ioutil.ReadAll(resp.Body)
+		a.Equal("done", string(body))
+	}
+	a.Nil(<-serveErr)
+}
+
+func TestApp_Serve_ShutdownTimeout(t *testing.T) {
+	a := assert.New(t)
+	app := newTestApp(&ServerConfig{ShutdownTimeout: 50 * time.Millisecond})
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	a.Nil(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- app.serve(ctx, handler, nil, []listener{{Listener: l}}) }()
+	go func() { _, _ = http.Get("http://" + l.Addr().String()) }()
+
+	// 超过 ShutdownTimeout 后强制关闭连接并返回错误
+	<-started
+	cancel()
+	select {
+	case err := <-serveErr:
+		a.NotNil(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("serve did not return after shutdown timeout")
+	}
+}
+
+func TestApp_Serve_AdminListener(t *testing.T) {
+	a := assert.New(t)
+	app := newTestApp(&ServerConfig{WriteTimeout: time.Second, ShutdownTimeout: time.Second})
+	listeners, err := listen([]ListenerConfig{{Addr: "127.0.0.1:0"}, {Addr: "127.0.0.1:0"}}, zap.NewNop())
+	a.Nil(err)
+	listeners[1].admin = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- app.serve(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("public"))
+		}), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// 管理接口监听地址不设置 WriteTimeout
+			server := r.Context().Value(http.ServerContextKey).(*http.Server)
+			_, _ = w.Write([]byte("admin " + server.WriteTimeout.String()))
+		}), listeners)
+	}()
+
+	for i, want := range []string{"public", "admin 0s"} {
+		resp, err := http.Get("http://" + listeners[i].Addr().String())
+		a.Nil(err)
+		body, _ := ioutil.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		a.Equal(want, string(body))
+	}
+	cancel()
+	a.Nil(<-serveErr)
+}
+
+// newTestApp 实例化仅用于测试 listen、serve 的 *App
+func newTestApp(serverConfig *ServerConfig) *App {
+	return &App{
+		serverConfig:   serverConfig,
+		zapLogger:      zap.NewNop(),
+		healthRegistry: health.NewRegistry(&health.Config{Timeout: time.Second}),
+	}
+}
+
+func TestApp_Serve_ShutdownDelay(t *testing.T) {
+	a := assert.New(t)
+	app := newTestApp(&ServerConfig{ShutdownDelay: 200 * time.Millisecond, ShutdownTimeout: time.Second})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	a.Nil(err)
+	url := "http://" + l.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- app.serve(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), nil, []listener{{Listener: l}})
+	}()
+
+	// 开始优雅关闭后立即标记为未就绪，ShutdownDelay 内仍接受新连接
+	cancel()
+	time.Sleep(50 * time.Millisecond)
+	a.True(app.healthRegistry.IsShuttingDown())
+	resp, err := http.Get(url)
+	if a.Nil(err) {
+		_ = resp.Body.Close()
+	}
+	a.Nil(<-serveErr)
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"github.com/patrickmn/go-cache"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"time"
+)
+
+// 本文件定义带链路追踪的缓存操作：每次操作记录为一个 span。
+// 缓存 key 可能包含手机号等敏感信息，span 中仅记录 key 前缀。
+
+// cacheGet 读取缓存
+func cacheGet(ctx context.Context, tracer trace.Tracer, c *cache.Cache, keyPrefix, key string) (interface{}, bool) {
+	_, span := startCacheSpan(ctx, tracer, "Get", keyPrefix)
+	defer span.End()
+	value, found := c.Get(keyPrefix + key)
+	span.SetAttributes(attribute.Bool("cache.hit", found))
+	return value, found
+}
+
+// cacheSet 写入缓存
+func cacheSet(ctx context.Context, tracer trace.Tracer, c *cache.Cache, keyPrefix, key string, value interface{}, expire time.Duration) {
+	_, span := startCacheSpan(ctx, tracer, "Set", keyPrefix)
+	defer span.End()
+	c.Set(keyPrefix+key, value, expire)
+}
+
+func startCacheSpan(ctx context.Context, tracer trace.Tracer, operation, keyPrefix string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "cache."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemKey.String("go-cache"),
+			semconv.DBOperationKey.String(operation),
+			attribute.String("cache.key_prefix", keyPrefix),
+		),
+	)
+}
@@ -1,6 +1,13 @@
 package service
 
-import "time"
+import (
+	"context"
+	"fmt"
+	"project/app/model"
+	"project/app/pkg/fieldmask"
+	"project/app/pkg/pagination"
+	"time"
+)
 
 // 客户端短信验证码发送请求频率超限错误
 type SmsRequestOutOfLimitError struct {
@@ -14,12 +21,63 @@ func (err *SmsRequestOutOfLimitError) Error() string {
 	return err.Message
 }
 
+// 资源不存在错误
+type NotFoundError struct {
+	// 资源类型，例：user
+	ResourceType string
+	// 资源名称，例：users/1
+	ResourceName string
+}
+
+func (err *NotFoundError) Error() string {
+	return fmt.Sprintf("%s `%s` not found", err.ResourceType, err.ResourceName)
+}
+
+// 资源已存在错误，例：创建、更新资源时唯一字段的值已被其它资源使用
+type AlreadyExistsError struct {
+	// 资源类型，例：user
+	ResourceType string
+	// 值已被使用的字段，例：username
+	Field string
+	// 错误描述
+	Message string
+}
+
+func (err *AlreadyExistsError) Error() string {
+	return err.Message
+}
+
 // 短信验证码服务接口
 type ISms interface {
 	// 向单个手机号发送短信验证码
 	//
 	// 客户端请求频率超限时，返回 *SmsRequestOutOfLimitError
-	Send(cellPhoneNumber string) error
+	Send(ctx context.Context, cellPhoneNumber string) error
 	// 验证短信验证码是否有效
-	Verify(cellPhoneNumber, code string) bool
+	Verify(ctx context.Context, cellPhoneNumber, code string) bool
+}
+
+// 用户服务接口
+type IUser interface {
+	// 分页列出符合 filterText 的用户，按 orderBy 排序（为空时按 id 升序排列），
+	// 返回本页用户及下一页的 page_token（没有下一页时为空字符串）。filter、order_by 可用字段见 dao.UserSchema
+	//
+	// page_token 无效时，返回 *pagination.Error；filter、order_by 有误时，返回 *filter.Error
+	List(ctx context.Context, page *pagination.Page, filterText, orderBy string) (users []*model.User, nextPageToken string, err error)
+	// 获取用户
+	//
+	// 用户不存在时，返回 *NotFoundError
+	Get(ctx context.Context, id int64) (*model.User, error)
+	// 创建用户，返回创建后的用户
+	//
+	// 用户名、邮箱已被使用时，返回 *AlreadyExistsError
+	Create(ctx context.Context, user *model.User) (*model.User, error)
+	// 更新用户 user.Id 中 mask 内的字段（用户名、显示名称、邮箱），返回更新后的用户
+	//
+	// 用户不存在时，返回 *NotFoundError；用户名、邮箱已被其他用户使用时，返回 *AlreadyExistsError
+	Update(ctx context.Context, user *model.User, mask *fieldmask.Mask) (*model.User, error)
+	// 删除用户
+	//
+	// 用户不存在时，返回 *NotFoundError
+	Delete(ctx context.Context, id int64) error
 }
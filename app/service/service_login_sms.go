@@ -1,65 +1,172 @@
 package service
 
 import (
+	"context"
+	"fmt"
 	"github.com/patrickmn/go-cache"
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"project/app/pkg/config"
+	"project/app/pkg/logger"
+	"project/app/pkg/metrics"
 	"project/app/pkg/sms"
 	"project/app/pkg/util"
+	"sync/atomic"
 	"time"
 )
 
+// LoginSmsConfig 为登录短信验证码业务配置，对应配置文件中的 `loginSms` 节点，支持热加载
+type LoginSmsConfig struct {
+	// 发送频率限制
+	RateLimit RateLimitConfig `mapstructure:"rateLimit"`
+}
+
+// RateLimitConfig 为发送频率限制：每个手机号在 Window 时间内最多发送 Max 次
+type RateLimitConfig struct {
+	Window time.Duration `mapstructure:"window" validate:"gt=0"`
+	Max    int           `mapstructure:"max" validate:"gt=0"`
+}
+
+// NewLoginSmsConfig 从 viper 中读取登录短信验证码业务配置
+func NewLoginSmsConfig(v *viper.Viper) (*LoginSmsConfig, error) {
+	cfg := &LoginSmsConfig{
+		RateLimit: RateLimitConfig{Window: 5 * time.Minute, Max: 10},
+	}
+	if err := config.Unmarshal(v, "loginSms", cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
 type LoginSmsService struct {
-	sender sms.Sender
-	cache  *cache.Cache
+	config  atomic.Value // *LoginSmsConfig，配置热加载时原子替换
+	sender  sms.Sender
+	cache   *cache.Cache
+	metrics *metrics.SmsMetrics
+	tracer  trace.Tracer
+	// 短信验证码发送日志，logger 名称：sms
+	zapLogger *zap.Logger
 }
 
 var _ ISms = new(LoginSmsService)
 
-func NewLoginSmsService(sender sms.Sender, cache *cache.Cache) *LoginSmsService {
-	return &LoginSmsService{
-		sender: sender,
-		cache:  cache,
+func NewLoginSmsService(
+	cfg *LoginSmsConfig,
+	sender sms.Sender,
+	cache *cache.Cache,
+	smsMetrics *metrics.SmsMetrics,
+	tracerProvider trace.TracerProvider,
+	zapLogger *zap.Logger,
+) *LoginSmsService {
+	service := &LoginSmsService{
+		sender:    sender,
+		cache:     cache,
+		metrics:   smsMetrics,
+		tracer:    tracerProvider.Tracer("project/app/service"),
+		zapLogger: zapLogger.Named("sms"),
 	}
+	service.SetConfig(cfg)
+	return service
+}
+
+// SetConfig 替换业务配置（配置热加载时调用），对之后的请求生效
+func (service *LoginSmsService) SetConfig(cfg *LoginSmsConfig) {
+	service.config.Store(cfg)
+}
+
+func (service *LoginSmsService) getConfig() *LoginSmsConfig {
+	return service.config.Load().(*LoginSmsConfig)
 }
 
 const (
 	loginSmsKeyPrefix = "login_cell_phone_number:"
-	loginSmsExpire    = 5
+	// 发送频率限制计数的缓存 key 前缀
+	loginSmsLimitKeyPrefix = "login_sms_limit:"
+	// 验证码有效期，单位：分钟
+	loginSmsExpire = 5
+	// 监控指标、链路追踪中的业务场景名称
+	loginSmsScene = "login"
 )
 
-func (service *LoginSmsService) Send(cnCellPhoneNumber string) error {
-	ok, retryDelay := service.sendSpeedLimit(cnCellPhoneNumber)
+func (service *LoginSmsService) Send(ctx context.Context, cnCellPhoneNumber string) (err error) {
+	ctx, span := service.tracer.Start(ctx, "LoginSmsService.Send",
+		trace.WithAttributes(attribute.String("sms.scene", loginSmsScene)))
+	defer func() {
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	rateLimit := service.getConfig().RateLimit
+	ok, retryDelay := service.sendSpeedLimit(rateLimit, cnCellPhoneNumber)
 	if !ok {
+		service.metrics.ObserveRateLimited(loginSmsScene)
+		span.SetAttributes(attribute.Bool("sms.rate_limited", true))
 		return &SmsRequestOutOfLimitError{
-			Message:    "登录短信验证码发送频率超限，5 分钟内最多发送 10 次",
+			Message:    fmt.Sprintf("登录短信验证码发送频率超限，%s内最多发送 %d 次", formatWindow(rateLimit.Window), rateLimit.Max),
 			RetryDelay: retryDelay,
 		}
 	}
 
 	code := util.GenerateRandomDigits(6)
-	if err := service.sender.Send(cnCellPhoneNumber, code, loginSmsExpire); err != nil {
-		return nil
+	sendErr := service.sender.Send(ctx, cnCellPhoneNumber, code, loginSmsExpire)
+	service.metrics.ObserveSend(service.sender.Provider(), loginSmsScene, sendErr)
+	zapLogger := logger.WithContext(ctx, service.zapLogger).With(zap.String("provider", service.sender.Provider()))
+	if sendErr != nil {
+		zapLogger.Warn("send login sms failed", zap.Error(sendErr))
+		return errors.Wrap(sendErr, "send login sms failed")
 	}
-	service.cache.Set(loginSmsKeyPrefix+cnCellPhoneNumber, cnCellPhoneNumber, loginSmsExpire)
+	zapLogger.Debug("login sms sent")
+	cacheSet(ctx, service.tracer, service.cache, loginSmsKeyPrefix, cnCellPhoneNumber, code, loginSmsExpire*time.Minute)
 	return nil
 }
 
-// 检测客户端请求登录短信验证码发送接口频率是否超限
-func (service *LoginSmsService) sendSpeedLimit(cnCellPhoneNumber string) (ok bool, retryDelay time.Duration) {
-	// @todo 以下为 mock 代码
-	boolean := time.Now().UnixNano()%2 == 0
-	if boolean {
+// 检测客户端请求登录短信验证码发送接口频率是否超限（固定窗口计数）
+func (service *LoginSmsService) sendSpeedLimit(rateLimit RateLimitConfig, cnCellPhoneNumber string) (ok bool, retryDelay time.Duration) {
+	key := loginSmsLimitKeyPrefix + cnCellPhoneNumber
+	// 窗口内首次发送
+	if err := service.cache.Add(key, 1, rateLimit.Window); err == nil {
+		return true, 0
+	}
+	count, err := service.cache.IncrementInt(key, 1)
+	if err != nil {
+		// 计数恰好过期
+		service.cache.Set(key, 1, rateLimit.Window)
+		return true, 0
+	}
+	if count <= rateLimit.Max {
 		return true, 0
-	} else {
-		return false, time.Minute * 5
 	}
+	_, expiration, _ := service.cache.GetWithExpiration(key)
+	return false, time.Until(expiration)
 }
 
-// 验证登录短信验证码是否有效
-func (service *LoginSmsService) Verify(cnCellPhoneNumber string, code string) bool {
-	data, ok := service.cache.Get(loginSmsKeyPrefix + cnCellPhoneNumber)
-	if ok && data.(string) == code {
-		return true
-	} else {
-		return false
+// formatWindow 格式化频率限制的时间窗口，例：5m -> 5 分钟
+func formatWindow(window time.Duration) string {
+	switch {
+	case window%time.Hour == 0:
+		return fmt.Sprintf("%d 小时", window/time.Hour)
+	case window%time.Minute == 0:
+		return fmt.Sprintf("%d 分钟", window/time.Minute)
+	default:
+		return window.String() + " "
 	}
 }
+
+// 验证登录短信验证码是否有效
+func (service *LoginSmsService) Verify(ctx context.Context, cnCellPhoneNumber string, code string) bool {
+	ctx, span := service.tracer.Start(ctx, "LoginSmsService.Verify",
+		trace.WithAttributes(attribute.String("sms.scene", loginSmsScene)))
+	defer span.End()
+
+	data, ok := cacheGet(ctx, service.tracer, service.cache, loginSmsKeyPrefix, cnCellPhoneNumber)
+	ok = ok && data.(string) == code
+	service.metrics.ObserveVerify(loginSmsScene, ok)
+	span.SetAttributes(attribute.Bool("sms.verified", ok))
+	return ok
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"project/app/pkg/cache"
+	"project/app/pkg/metrics"
+	"testing"
+	"time"
+)
+
+func TestLoginSmsService_SendSpeedLimit(t *testing.T) {
+	a := assert.New(t)
+	service := NewLoginSmsService(
+		&LoginSmsConfig{RateLimit: RateLimitConfig{Window: time.Minute, Max: 2}},
+		nil, cache.NewGoCache(), nil, trace.NewNoopTracerProvider(), zap.NewNop(),
+	)
+
+	for i := 0; i < 2; i++ {
+		ok, _ := service.sendSpeedLimit(service.getConfig().RateLimit, "13812341234")
+		a.True(ok)
+	}
+	ok, retryDelay := service.sendSpeedLimit(service.getConfig().RateLimit, "13812341234")
+	a.False(ok)
+	a.True(retryDelay > 0 && retryDelay <= time.Minute)
+	// 每个手机号单独计数
+	ok, _ = service.sendSpeedLimit(service.getConfig().RateLimit, "13812341235")
+	a.True(ok)
+
+	// 热加载后新的频率限制立即生效
+	service.SetConfig(&LoginSmsConfig{RateLimit: RateLimitConfig{Window: time.Minute, Max: 5}})
+	ok, _ = service.sendSpeedLimit(service.getConfig().RateLimit, "13812341234")
+	a.True(ok)
+}
+
+// fakeSender 记录最近一次发送的验证码，err 不为 nil 时发送失败
+type fakeSender struct {
+	code string
+	err  error
+}
+
+func (s *fakeSender) Send(ctx context.Context, cellPhoneNumber string, code string, expire int) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.code = code
+	return nil
+}
+
+func (s *fakeSender) Provider() string {
+	return "fake"
+}
+
+func TestLoginSmsService_SendVerify(t *testing.T) {
+	a := assert.New(t)
+	smsMetrics, err := metrics.NewSmsMetrics(&metrics.Config{}, prometheus.NewRegistry())
+	a.Nil(err)
+	sender := &fakeSender{}
+	service := NewLoginSmsService(
+		&LoginSmsConfig{RateLimit: RateLimitConfig{Window: time.Minute, Max: 10}},
+		sender, cache.NewGoCache(), smsMetrics, trace.NewNoopTracerProvider(), zap.NewNop(),
+	)
+	ctx := context.Background()
+
+	a.Nil(service.Send(ctx, "13812341234"))
+	a.Len(sender.code, 6)
+	a.False(service.Verify(ctx, "13812341234", "13812341234"))
+	a.True(service.Verify(ctx, "13812341234", sender.code))
+	a.False(service.Verify(ctx, "13812341235", sender.code))
+
+	// 发送失败时返回错误，不缓存验证码
+	sender.err = errors.New("provider unavailable")
+	a.NotNil(service.Send(ctx, "13812341235"))
+	a.False(service.Verify(ctx, "13812341235", sender.code))
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"project/app/dao"
+	"project/app/model"
+	"project/app/pkg/fieldmask"
+	"project/app/pkg/pagination"
+)
+
+type UserService struct {
+	userDao *dao.UserDao
+}
+
+var _ IUser = new(UserService)
+
+func NewUserService(userDao *dao.UserDao) *UserService {
+	return &UserService{userDao: userDao}
+}
+
+// userCursor 为用户列表的分页游标：上一页最后一个用户的排序键（see: filter.OrderBy.EncodeKeys()）
+type userCursor struct {
+	Keys []json.RawMessage `json:"k"`
+}
+
+func (s *UserService) List(ctx context.Context, page *pagination.Page, filterText, orderByText string) ([]*model.User, string, error) {
+	f, err := dao.UserSchema.ParseFilter(filterText)
+	if err != nil {
+		return nil, "", err
+	}
+	orderBy, err := dao.UserSchema.ParseOrderBy(orderByText)
+	if err != nil {
+		return nil, "", err
+	}
+	var after []interface{}
+	if !page.First() {
+		var cursor userCursor
+		if err := page.Cursor(&cursor); err != nil {
+			return nil, "", err
+		}
+		var ok bool
+		if after, ok = orderBy.DecodeKeys(cursor.Keys); !ok {
+			return nil, "", &pagination.Error{Field: pagination.FieldPageToken, Description: pagination.FieldPageToken + "无效"}
+		}
+	}
+	// 多查询一个用户，以判断是否有下一页
+	users, err := s.userDao.List(ctx, f, orderBy, after, page.Size+1)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(users) <= page.Size {
+		return users, "", nil
+	}
+	users = users[:page.Size]
+	nextPageToken, err := page.NextToken(userCursor{Keys: orderBy.EncodeKeys(users[len(users)-1])})
+	if err != nil {
+		return nil, "", err
+	}
+	return users, nextPageToken, nil
+}
+
+func (s *UserService) Get(ctx context.Context, id int64) (*model.User, error) {
+	user, err := s.userDao.Get(ctx, id)
+	if err != nil {
+		return nil, userError(id, nil, err)
+	}
+	return user, nil
+}
+
+func (s *UserService) Create(ctx context.Context, user *model.User) (*model.User, error) {
+	if err := s.userDao.Create(ctx, user); err != nil {
+		return nil, userError(0, user, err)
+	}
+	return user, nil
+}
+
+func (s *UserService) Update(ctx context.Context, user *model.User, mask *fieldmask.Mask) (*model.User, error) {
+	// 仅更新 update_mask 中的列，避免并发的部分更新互相覆盖
+	if err := s.userDao.Update(ctx, user, mask.Paths()); err != nil {
+		return nil, userError(user.Id, user, err)
+	}
+	// 返回完整的用户信息
+	return s.Get(ctx, user.Id)
+}
+
+func (s *UserService) Delete(ctx context.Context, id int64) error {
+	if err := s.userDao.Delete(ctx, id); err != nil {
+		return userError(id, nil, err)
+	}
+	return nil
+}
+
+// userError 将 dao 层错误转换为 IUser 接口定义的错误：dao.ErrNotFound -> *NotFoundError，
+// *dao.DuplicateError -> *AlreadyExistsError，其它错误原样返回
+func userError(id int64, user *model.User, err error) error {
+	cause := errors.Cause(err)
+	if cause == dao.ErrNotFound {
+		return &NotFoundError{ResourceType: model.UserResourceType, ResourceName: model.UserResourceName(id)}
+	}
+	duplicate, ok := cause.(*dao.DuplicateError)
+	if !ok {
+		return err
+	}
+	var value string
+	if user != nil {
+		switch duplicate.Field {
+		case "username":
+			value = user.Username
+		case "email":
+			value = user.Email
+		}
+	}
+	return &AlreadyExistsError{
+		ResourceType: model.UserResourceType,
+		Field:        duplicate.Field,
+		Message:      fmt.Sprintf("%s `%s` is already in use", duplicate.Field, value),
+	}
+}
@@ -0,0 +1,22 @@
+package helper
+
+import (
+	"database/sql"
+	"go.uber.org/zap"
+	"project/app/dao"
+	"project/app/pkg/db"
+	"testing"
+)
+
+// NewTestDB 实例化一个已执行所有迁移的 sqlite 内存数据库，测试结束后关闭
+func NewTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	// 内存数据库随连接关闭而销毁，因此只使用一个连接并保持空闲
+	cfg := &db.Config{Driver: "sqlite", DSN: "file::memory:", MaxOpenConns: 1, MaxIdleConns: 1, AutoMigrate: true}
+	database, cleanup, err := db.NewDB(cfg, dao.NewMigrations(), zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cleanup)
+	return database
+}
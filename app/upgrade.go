@@ -0,0 +1,50 @@
+package app
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"net"
+	"os"
+	"os/signal"
+	"project/app/pkg/upgrade"
+	"sync/atomic"
+)
+
+// watchUpgrade 收到 upgradeSignals 信号后执行不停机升级：启动新进程并传递所有 listener，
+// 新进程就绪后调用 shutdown 优雅关闭当前进程；升级失败时当前进程继续提供服务。
+func (app *App) watchUpgrade(ctx context.Context, shutdown func(), listeners []listener) {
+	if len(upgradeSignals) == 0 {
+		return
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, upgradeSignals...)
+	defer signal.Stop(ch)
+
+	upgradeListeners := make([]upgrade.Listener, 0, len(listeners))
+	for _, l := range listeners {
+		upgradeListeners = append(upgradeListeners, upgrade.Listener{Addr: l.addr, Listener: l.raw})
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-ch:
+			app.upgradeLogger.Info("upgrade started", zap.String("signal", sig.String()))
+			pid, err := upgrade.Upgrade(upgradeListeners, app.serverConfig.UpgradeTimeout)
+			if err != nil {
+				app.upgradeLogger.Error("upgrade failed", zap.Error(err))
+				continue
+			}
+			// unix socket 文件由新进程继续使用，当前进程关闭 listener 时不删除
+			for _, l := range listeners {
+				if unixListener, ok := l.raw.(*net.UnixListener); ok {
+					unixListener.SetUnlinkOnClose(false)
+				}
+			}
+			app.upgradeLogger.Info("upgrade completed, shutting down", zap.Int("pid", pid))
+			atomic.StoreInt32(&app.upgraded, 1)
+			shutdown()
+			return
+		}
+	}
+}
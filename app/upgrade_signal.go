@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package app
+
+import (
+	"os"
+	"syscall"
+)
+
+// upgradeSignals 为触发不停机升级的信号
+var upgradeSignals = []os.Signal{syscall.SIGHUP, syscall.SIGUSR2}
@@ -0,0 +1,6 @@
+package app
+
+import "os"
+
+// upgradeSignals 为触发不停机升级的信号，windows 下不支持不停机升级
+var upgradeSignals []os.Signal
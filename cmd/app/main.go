@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"github.com/pkg/errors"
+	"os"
 	"project/app/handler/pkg/ginvalidator"
 	"project/app/pkg/config"
 )
@@ -12,20 +13,21 @@ var (
 	// 模板配置文件路径
 	configTemplateFile = flag.String(
 		"config_template",
-		//"template.yaml",
-		"c:/projects/go-http-api-sample/app/config/template.yaml",
+		"template.yaml",
 		"set config template file which viper will loading.",
 	)
-	// 机密配置文件路径
+	// 机密配置文件路径，可选：为空或文件不存在时忽略，机密配置项可由 APP_* 环境变量提供
 	configSecretFile = flag.String(
 		"config_secret",
-		//"secret.yaml",
-		"c:/projects/go-http-api-sample/app/config/secret.yaml",
-		"set config secret file which viper will loading.",
+		"secret.yaml",
+		"set config secret file which viper will loading, optional: ignored when empty or missing.",
 	)
+	// 通过命令行参数覆盖的配置项，可重复，例：--set aliyunLoginSms.regionId=cn-hangzhou --set isDebug=false
+	configOverrides config.Overrides
 )
 
 func main() {
+	flag.Var(&configOverrides, "set", "override config item which viper will loading, format: key=value, repeatable.")
 	flag.Parse()
 
 	// 初始化 gin 内部使用的 validator，如：注册自定义验证器、注册翻译器等...
@@ -34,12 +36,18 @@ func main() {
 		panic(errors.Wrap(err, "gin validator initialize failed"))
 	}
 
-	app, cleanup, err := CreateApp(config.FilePath(*configTemplateFile), config.FilePath(*configSecretFile))
+	app, cleanup, err := CreateApp(configOverrides, config.FilePath(*configTemplateFile), config.FilePath(*configSecretFile))
 	if err != nil {
-		panic(err)
-	} else {
-		defer cleanup()
-		fmt.Println(app.Run())
+		// 配置错误等启动失败原因，如：invalid config: 列出的所有问题
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	// app.Run() 在收到 SIGINT、SIGTERM 信号并优雅关闭后返回，随后执行 cleanup（如：日志 Sync）
+	err = app.Run()
+	cleanup()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
 	}
 	// ./app.exe -config_template=C:/projects/go-http-api-sample/app/config/template.yaml --config_secret=C:/projects/go-http-api-sample/app/config/secret.yaml
 }
@@ -0,0 +1,174 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestUpgrade 编译并启动 app，持续发送请求的同时发送 SIGHUP 信号触发不停机升级，
+// 验证新进程接管 listener、旧进程优雅退出，且升级期间没有请求失败。
+func TestUpgrade(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skip integration test in short mode")
+	}
+	a := assert.New(t)
+	dir, err := ioutil.TempDir("", "upgrade")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+
+	binary := filepath.Join(dir, "app")
+	build := exec.Command("go", "build", "-o", binary, ".")
+	out, err := build.CombinedOutput()
+	if !a.Nil(err, string(out)) {
+		return
+	}
+
+	addr := freeAddr(t)
+	configFile := filepath.Join(dir, "config.yaml")
+	a.Nil(ioutil.WriteFile(configFile, []byte(fmt.Sprintf(`
+isDebug: false
+addr:
+  - %q
+server:
+  upgradeTimeout: 10s
+panicReport:
+  file: ""
+db:
+  dsn: %q
+`, addr, "file:"+filepath.Join(dir, "app.db"))), 0600))
+
+	logFile := filepath.Join(dir, "app.log")
+	stdout, err := os.Create(logFile)
+	a.Nil(err)
+	defer stdout.Close()
+	cmd := exec.Command(binary,
+		"-config_template", filepath.Join("..", "..", "app", "config", "template.yaml"),
+		"-config_secret", configFile,
+	)
+	cmd.Stdout, cmd.Stderr = stdout, stdout
+	a.Nil(cmd.Start())
+	defer func() { _ = cmd.Process.Kill() }()
+
+	url := "http://" + addr + "/upgrade-test"
+	client := &http.Client{
+		Transport: &http.Transport{DisableKeepAlives: true},
+		Timeout:   5 * time.Second,
+	}
+	if !a.True(waitFor(func() bool { return get(client, url) == nil }), "app not started") {
+		return
+	}
+
+	// 升级期间持续发送请求
+	var (
+		wg       sync.WaitGroup
+		stop     int32
+		requests int64
+		failures int64
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&stop) == 0 {
+				atomic.AddInt64(&requests, 1)
+				if err := get(client, url); err != nil {
+					atomic.AddInt64(&failures, 1)
+					t.Log(err)
+				}
+			}
+		}()
+	}
+
+	a.Nil(cmd.Process.Signal(syscall.SIGHUP))
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	select {
+	case err := <-exited:
+		a.Nil(err)
+	case <-time.After(30 * time.Second):
+		t.Fatal("old process did not exit after upgrade")
+	}
+
+	// 旧进程退出后，请求由新进程处理
+	time.Sleep(200 * time.Millisecond)
+	atomic.StoreInt32(&stop, 1)
+	wg.Wait()
+	a.NotZero(atomic.LoadInt64(&requests))
+	a.Zero(atomic.LoadInt64(&failures))
+	a.Nil(get(client, url))
+
+	pid := upgradedPid(t, logFile)
+	if !a.NotZero(pid) {
+		return
+	}
+	a.NotEqual(cmd.Process.Pid, pid)
+	a.Nil(syscall.Kill(pid, syscall.SIGTERM))
+	a.True(waitFor(func() bool { return get(client, url) != nil }), "new process did not stop")
+}
+
+// freeAddr 返回一个当前空闲的本地 tcp 地址
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+// get 发送 GET 请求，收到任意 http 响应即视为成功
+func get(client *http.Client, url string) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	_, _ = ioutil.ReadAll(resp.Body)
+	return resp.Body.Close()
+}
+
+// waitFor 在 10s 内轮询直到 cond 返回 true
+func waitFor(cond func() bool) bool {
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); {
+		if cond() {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return false
+}
+
+// upgradedPid 从日志中读取新进程的 pid
+func upgradedPid(t *testing.T, logFile string) int {
+	f, err := os.Open(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry struct {
+			Msg string `json:"msg"`
+			Pid int    `json:"pid"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil && entry.Msg == "upgrade completed, shutting down" {
+			return entry.Pid
+		}
+	}
+	return 0
+}
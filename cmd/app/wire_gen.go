@@ -6,44 +6,217 @@
 package main
 
 import (
-	"github.com/google/wire"
 	"project/app"
+	"project/app/dao"
 	"project/app/handler"
 	"project/app/pkg/cache"
 	"project/app/pkg/config"
+	"project/app/pkg/db"
+	"project/app/pkg/health"
+	"project/app/pkg/logger"
+	"project/app/pkg/metrics"
+	"project/app/pkg/pagination"
+	"project/app/pkg/panicreport"
+	"project/app/pkg/redact"
 	"project/app/pkg/sms"
+	"project/app/pkg/tracing"
 	"project/app/service"
 )
 
 // Injectors from wire.go:
 
-func CreateApp(configFiles ...config.FilePath) (*app.App, func(), error) {
-	viper, err := config.NewViper(configFiles...)
+func CreateApp(overrides config.Overrides, configFiles ...config.FilePath) (*app.App, func(), error) {
+	viper, err := app.NewViper(overrides, configFiles...)
 	if err != nil {
 		return nil, nil, err
 	}
 	isDebug := config.NewIsDebug(viper)
-	httpAddresses := app.NewHttpAddresses(viper)
-	logger, cleanup, err := handler.NewZapLogger(isDebug, viper)
+	listenerConfigs, err := app.NewListenerConfigs(viper)
 	if err != nil {
 		return nil, nil, err
 	}
-	loggerMiddleware := handler.NewLoggerMiddleware(logger)
-	recoveryMiddleware := _wireRecoveryMiddlewareValue
-	aliyunLoginSms := sms.NewAliyunLoginSms(viper)
+	adminConfig, err := handler.NewAdminConfig(viper)
+	if err != nil {
+		return nil, nil, err
+	}
+	adminListenerConfigs, err := app.NewAdminListenerConfigs(viper, adminConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	serverConfig, err := app.NewServerConfig(viper)
+	if err != nil {
+		return nil, nil, err
+	}
+	loggerConfig, err := logger.NewConfig(isDebug, viper)
+	if err != nil {
+		return nil, nil, err
+	}
+	levelController, err := logger.NewLevelController(loggerConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	zapLogger, cleanup, err := logger.NewZapLogger(isDebug, loggerConfig, levelController)
+	if err != nil {
+		return nil, nil, err
+	}
+	healthConfig, err := health.NewConfig(viper)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
 	cacheCache := cache.NewGoCache()
-	loginSmsService := service.NewLoginSmsService(aliyunLoginSms, cacheCache)
+	aliyunConfig, err := sms.NewAliyunConfig(viper)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	tracingConfig, err := tracing.NewConfig(viper)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	tracerProvider, cleanup2, err := tracing.NewTracerProvider(tracingConfig, zapLogger)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	aliyunLoginSms := sms.NewAliyunLoginSms(aliyunConfig, tracerProvider)
+	dbConfig, err := db.NewConfig(viper)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	migrations := dao.NewMigrations()
+	sqlDB, cleanup3, err := db.NewDB(dbConfig, migrations, zapLogger)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	registry := app.NewHealthRegistry(healthConfig, cacheCache, aliyunLoginSms, sqlDB)
+	requestIdMiddleware := _wireRequestIdMiddlewareValue
+	redactor, err := redact.NewRedactor(viper)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	tracingMiddleware := handler.NewTracingMiddleware(tracerProvider, redactor)
+	bodyCaptureConfig, err := handler.NewBodyCaptureConfig(viper)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	loggerMiddleware := handler.NewLoggerMiddleware(zapLogger, redactor, bodyCaptureConfig)
+	metricsConfig, err := metrics.NewConfig(viper)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	prometheusRegistry, err := metrics.NewRegistry()
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	httpMetrics, err := metrics.NewHttpMetrics(metricsConfig, prometheusRegistry)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	metricsMiddleware := handler.NewMetricsMiddleware(httpMetrics)
+	panicreportConfig, err := panicreport.NewConfig(viper)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	logNotifier := panicreport.NewLogNotifier(zapLogger)
+	recorder, cleanup4, err := panicreport.NewRecorder(panicreportConfig, logNotifier, redactor, zapLogger)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	recoveryMiddleware := handler.NewRecoveryMiddleware(isDebug, zapLogger, recorder, redactor)
+	clientCertMiddleware := _wireClientCertMiddlewareValue
+	adminAuthMiddleware := handler.NewAdminAuthMiddleware(adminConfig)
+	loginSmsConfig, err := service.NewLoginSmsConfig(viper)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	smsMetrics, err := metrics.NewSmsMetrics(metricsConfig, prometheusRegistry)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	loginSmsService := service.NewLoginSmsService(loginSmsConfig, aliyunLoginSms, cacheCache, smsMetrics, tracerProvider, zapLogger)
 	loginSmsCtrl := handler.NewLoginSmsCtrl(loginSmsService)
-	appApp := app.NewApp(isDebug, httpAddresses, loggerMiddleware, recoveryMiddleware, loginSmsCtrl)
+	logLevelCtrl := handler.NewLogLevelCtrl(levelController)
+	panicReportCtrl := handler.NewPanicReportCtrl(recorder)
+	healthCtrl := handler.NewHealthCtrl(registry)
+	metricsCtrl := handler.NewMetricsCtrl(prometheusRegistry)
+	reloadConfig, err := config.NewReloadConfig(viper)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	schema := app.NewConfigSchema()
+	reloader, cleanup5, err := app.NewConfigReloader(reloadConfig, viper, overrides, configFiles, schema, loggerConfig, levelController, loginSmsService, zapLogger)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	debugCtrl := handler.NewDebugCtrl(reloader, redactor)
+	configCtrl := handler.NewConfigCtrl(reloader)
+	userDao := dao.NewUserDao(sqlDB)
+	userService := service.NewUserService(userDao)
+	paginationConfig, err := pagination.NewConfig(viper)
+	if err != nil {
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	paginator := pagination.NewPaginator(paginationConfig)
+	userCtrl := handler.NewUserCtrl(userService, paginator)
+	appApp := app.NewApp(isDebug, listenerConfigs, adminListenerConfigs, serverConfig, zapLogger, registry, requestIdMiddleware, tracingMiddleware, loggerMiddleware, metricsMiddleware, recoveryMiddleware, clientCertMiddleware, adminAuthMiddleware, loginSmsCtrl, logLevelCtrl, panicReportCtrl, healthCtrl, metricsCtrl, debugCtrl, configCtrl, userCtrl)
 	return appApp, func() {
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
 		cleanup()
 	}, nil
 }
 
 var (
-	_wireRecoveryMiddlewareValue = &handler.RecoveryMiddleware{}
+	_wireRequestIdMiddlewareValue  = &handler.RequestIdMiddleware{}
+	_wireClientCertMiddlewareValue = &handler.ClientCertMiddleware{}
 )
-
-// wire.go:
-
-var providerSet = wire.NewSet(config.NewViper, config.NewIsDebug, cache.NewGoCache, app.NewApp, app.NewHttpAddresses, handler.NewLoggerMiddleware, handler.NewZapLogger, wire.Value(&handler.RecoveryMiddleware{}), handler.NewLoginSmsCtrl, service.NewLoginSmsService, wire.Bind(new(service.ISms), new(*service.LoginSmsService)), sms.NewAliyunLoginSms, wire.Bind(new(sms.Sender), new(*sms.AliyunLoginSms)))
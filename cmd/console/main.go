@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"project/app/pkg/config"
+	"project/console"
+	"strings"
+	"time"
+)
+
+// 退出码，便于脚本判断执行结果
+const (
+	exitOK      = 0
+	exitFailure = 1 // 命令执行失败，如：短信发送失败、迁移失败
+	exitUsage   = 2 // 子命令或参数错误
+	exitConfig  = 3 // 配置错误：读取配置文件失败或配置校验失败
+)
+
+// exitError 为指定退出码的错误
+type exitError struct {
+	code int
+	err  error
+}
+
+func (err *exitError) Error() string {
+	return err.err.Error()
+}
+
+// usageError 返回退出码为 exitUsage 的错误
+func usageError(err error) error {
+	return &exitError{code: exitUsage, err: err}
+}
+
+// exitCodeOf 返回错误对应的退出码：配置校验错误为 exitConfig，其它未指定退出码的错误为 exitFailure
+func exitCodeOf(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	if ee, ok := err.(*exitError); ok {
+		return ee.code
+	}
+	if _, ok := errors.Cause(err).(*config.ValidationError); ok {
+		return exitConfig
+	}
+	return exitFailure
+}
+
+// env 为子命令的运行环境
+type env struct {
+	// 通过命令行参数覆盖的配置项
+	overrides config.Overrides
+	// 配置文件路径
+	configFiles []config.FilePath
+	stdin       io.Reader
+	stdout      io.Writer
+}
+
+// newFlagSet 实例化子命令的参数解析器，帮助信息输出到 stdout
+func (env *env) newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(env.stdout)
+	return flags
+}
+
+// command 为 console 子命令
+type command struct {
+	// 子命令名称，例：config check
+	name string
+	// 用法说明
+	usage string
+	run   func(env *env, args []string) error
+}
+
+// commands 为所有子命令
+var commands = []command{
+	{name: "config check", usage: "校验配置（与 app 启动时相同），配置有误时列出所有问题", run: configCheck},
+	{name: "config print", usage: "[-redact] 以 yaml 格式输出生效的配置（已解密），-redact 时机密配置项脱敏", run: configPrint},
+	{name: "sms send-test", usage: "-phone <number> 发送一条测试验证码，检查短信服务配置", run: smsSendTest},
+	{name: "routes", usage: "输出业务接口、管理接口的路由表", run: routes},
+	{name: "codes list", usage: "输出所有业务错误码", run: codesList},
+	{name: "db migrate", usage: "[-dry_run] 执行数据库迁移，-dry_run 时仅列出未执行的迁移", run: dbMigrate},
+	{name: "secret keygen", usage: "生成加密配置值使用的密钥", run: secretKeygen},
+	{name: "secret encrypt", usage: "[value] 加密配置值，省略 value 时从标准输入读取", run: secretEncrypt},
+	{name: "secret decrypt", usage: "<ENC[...]> 解密配置值", run: secretDecrypt},
+	{name: "secret rotate", usage: "-file <path> -new_key <key> | -new_key_file <path> 使用新密钥重新加密配置文件中的所有密文", run: secretRotate},
+}
+
+func main() {
+	err := run(os.Args[1:], os.Stdin, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(exitCodeOf(err))
+	// ./console -config_template=app/config/template.yaml -config_secret=app/config/secret.yaml config check
+}
+
+// run 解析全局参数（配置文件路径等，与 app 相同），按其余参数匹配子命令并执行，未匹配时输出用法说明
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	env := &env{stdin: stdin, stdout: stdout}
+	flags := flag.NewFlagSet("console", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	configTemplateFile := flags.String("config_template", "template.yaml", "set config template file which viper will loading.")
+	configSecretFile := flags.String("config_secret", "secret.yaml", "set config secret file which viper will loading, optional: ignored when empty or missing.")
+	flags.Var(&env.overrides, "set", "override config item which viper will loading, format: key=value, repeatable.")
+	err := flags.Parse(args)
+	if err == nil {
+		env.configFiles = []config.FilePath{config.FilePath(*configTemplateFile), config.FilePath(*configSecretFile)}
+		args = flags.Args()
+		for _, cmd := range commands {
+			if matchCommand(cmd.name, args) {
+				err := cmd.run(env, args[len(strings.Fields(cmd.name)):])
+				if err == flag.ErrHelp {
+					return nil
+				}
+				return err
+			}
+		}
+	}
+
+	fmt.Fprintln(stdout, "usage: console [flags] <command> [arguments]")
+	fmt.Fprintln(stdout, "flags:")
+	flags.SetOutput(stdout)
+	flags.PrintDefaults()
+	fmt.Fprintln(stdout, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(stdout, "  %s %s\n", cmd.name, cmd.usage)
+	}
+	switch {
+	case err == flag.ErrHelp:
+		return nil
+	case err != nil:
+		return usageError(err)
+	case len(args) == 0:
+		return usageError(errors.New("command is required"))
+	default:
+		return usageError(errors.Errorf("unknown command `%s`", strings.Join(args, " ")))
+	}
+}
+
+// matchCommand 判断参数是否以子命令名称开头
+func matchCommand(name string, args []string) bool {
+	parts := strings.Fields(name)
+	if len(args) < len(parts) {
+		return false
+	}
+	for i, part := range parts {
+		if args[i] != part {
+			return false
+		}
+	}
+	return true
+}
+
+func configCheck(env *env, args []string) error {
+	if err := env.newFlagSet("config check").Parse(args); err != nil {
+		return usageError(err)
+	}
+	if err := console.CheckConfig(env.stdout, env.overrides, env.configFiles...); err != nil {
+		// 配置文件不存在等错误同样视为配置错误
+		return &exitError{code: exitConfig, err: err}
+	}
+	return nil
+}
+
+func configPrint(env *env, args []string) error {
+	flags := env.newFlagSet("config print")
+	redacted := flags.Bool("redact", false, "redact secret config items.")
+	if err := flags.Parse(args); err != nil {
+		return usageError(err)
+	}
+	cmd, cleanup, err := createConfigCmd(env.overrides, env.configFiles...)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return cmd.Print(env.stdout, *redacted)
+}
+
+func smsSendTest(env *env, args []string) error {
+	flags := env.newFlagSet("sms send-test")
+	phone := flags.String("phone", "", "cell phone number which the test code will be sent to.")
+	if err := flags.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if *phone == "" {
+		return usageError(errors.New("-phone is required"))
+	}
+	cmd, cleanup, err := createSmsCmd(env.overrides, env.configFiles...)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return cmd.SendTest(ctx, env.stdout, *phone)
+}
+
+func routes(env *env, args []string) error {
+	if err := env.newFlagSet("routes").Parse(args); err != nil {
+		return usageError(err)
+	}
+	// 仅构建路由表：不连接数据库（见 createRoutesCmd），不检查配置文件变更，不导出链路追踪数据
+	overrides := append(config.Overrides{"reload.interval=0s", "tracing.exporter=none"}, env.overrides...)
+	cmd, cleanup, err := createRoutesCmd(overrides, env.configFiles...)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return cmd.Print(env.stdout)
+}
+
+func codesList(env *env, args []string) error {
+	if err := env.newFlagSet("codes list").Parse(args); err != nil {
+		return usageError(err)
+	}
+	return console.PrintCodes(env.stdout)
+}
+
+func dbMigrate(env *env, args []string) error {
+	flags := env.newFlagSet("db migrate")
+	dryRun := flags.Bool("dry_run", false, "only list pending migrations.")
+	if err := flags.Parse(args); err != nil {
+		return usageError(err)
+	}
+	// 由本命令执行迁移并输出结果，实例化数据库时不自动执行迁移
+	overrides := append(config.Overrides{"db.autoMigrate=false"}, env.overrides...)
+	cmd, cleanup, err := createDbCmd(overrides, env.configFiles...)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	return cmd.Migrate(context.Background(), env.stdout, *dryRun)
+}
+
+func secretKeygen(env *env, args []string) error {
+	if err := env.newFlagSet("secret keygen").Parse(args); err != nil {
+		return usageError(err)
+	}
+	return console.SecretKeygen(env.stdout)
+}
+
+func secretEncrypt(env *env, args []string) error {
+	if len(args) > 0 {
+		return console.SecretEncrypt(env.stdout, args[0])
+	}
+	data, err := ioutil.ReadAll(env.stdin)
+	if err != nil {
+		return errors.Wrap(err, "read stdin failed")
+	}
+	return console.SecretEncrypt(env.stdout, strings.TrimRight(string(data), "\r\n"))
+}
+
+func secretDecrypt(env *env, args []string) error {
+	if len(args) == 0 {
+		return usageError(errors.New("encrypted value is required"))
+	}
+	return console.SecretDecrypt(env.stdout, args[0])
+}
+
+func secretRotate(env *env, args []string) error {
+	flags := env.newFlagSet("secret rotate")
+	file := flags.String("file", "", "config file to rotate, e.g. secret.yaml.")
+	newKey := flags.String("new_key", "", "new key (base64).")
+	newKeyFile := flags.String("new_key_file", "", "file containing the new key (base64).")
+	if err := flags.Parse(args); err != nil {
+		return usageError(err)
+	}
+	if *file == "" {
+		return usageError(errors.New("-file is required"))
+	}
+	if (*newKey == "") == (*newKeyFile == "") {
+		return usageError(errors.New("exactly one of -new_key and -new_key_file is required"))
+	}
+	if *newKeyFile != "" {
+		data, err := ioutil.ReadFile(*newKeyFile)
+		if err != nil {
+			return errors.Wrap(err, "read new key file failed")
+		}
+		*newKey = strings.TrimSpace(string(data))
+	}
+	return console.SecretRotate(env.stdout, *file, *newKey)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"project/app/pkg/config"
+	"strings"
+	"testing"
+)
+
+func TestSecretCommands(t *testing.T) {
+	a := assert.New(t)
+	exec := func(stdin string, args ...string) (string, error) {
+		var stdout bytes.Buffer
+		err := run(args, strings.NewReader(stdin), &stdout)
+		return strings.TrimSpace(stdout.String()), err
+	}
+
+	oldKey, err := exec("", "secret", "keygen")
+	a.Nil(err)
+	newKey, err := exec("", "secret", "keygen")
+	a.Nil(err)
+
+	// 未设置密钥
+	setenv(t, config.SecretKeyEnv, "")
+	_, err = exec("", "secret", "encrypt", "your-value")
+	a.NotNil(err)
+
+	setenv(t, config.SecretKeyEnv, oldKey)
+	value, err := exec("your-value\n", "secret", "encrypt")
+	a.Nil(err)
+	plaintext, err := exec("", "secret", "decrypt", value)
+	a.Nil(err)
+	a.Equal("your-value", plaintext)
+
+	dir, err := ioutil.TempDir("", "console")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "secret.yaml")
+	a.Nil(ioutil.WriteFile(file, []byte("admin:\n  token: "+value+"\n"), 0600))
+
+	output, err := exec("", "secret", "rotate", "-file", file, "-new_key", newKey)
+	a.Nil(err)
+	a.Contains(output, "1 encrypted values rotated")
+	setenv(t, config.SecretKeyEnv, newKey)
+	v, err := config.NewViper(nil, config.FilePath(file))
+	a.Nil(err)
+	a.Equal("your-value", v.GetString("admin.token"))
+	info, err := os.Stat(file)
+	a.Nil(err)
+	a.Equal(os.FileMode(0600), info.Mode().Perm())
+
+}
+
+func TestRun(t *testing.T) {
+	a := assert.New(t)
+	exec := func(args ...string) (string, int) {
+		var stdout bytes.Buffer
+		err := run(args, strings.NewReader(""), &stdout)
+		return stdout.String(), exitCodeOf(err)
+	}
+	configFlags := []string{"-config_template=../../app/config/template.yaml", "-config_secret=../../app/config/secret.yaml"}
+
+	output, code := exec()
+	a.Equal(exitUsage, code)
+	a.Contains(output, "config check")
+	_, code = exec("unknown")
+	a.Equal(exitUsage, code)
+	_, code = exec("-h")
+	a.Equal(exitOK, code)
+	_, code = exec("secret", "rotate", "-unknown")
+	a.Equal(exitUsage, code)
+
+	output, code = exec(append(configFlags, "config", "check")...)
+	a.Equal(exitOK, code)
+	a.Contains(output, "config ok")
+	_, code = exec(append(configFlags, "--set", "server.shutdownTimeout=0s", "config", "check")...)
+	a.Equal(exitConfig, code)
+	_, code = exec("-config_template=not_exists.yaml", "config", "check")
+	a.Equal(exitConfig, code)
+
+	output, code = exec(append(configFlags, "--set", "admin.token=secret-token", "config", "print", "-redact")...)
+	a.Equal(exitOK, code)
+	a.Contains(output, "shutdowntimeout: 30s")
+	a.NotContains(output, "secret-token")
+
+	// routes 不连接数据库：不创建数据库文件
+	dir, err := ioutil.TempDir("", "console")
+	a.Nil(err)
+	defer os.RemoveAll(dir)
+	dbFile := filepath.Join(dir, "runtime", "app.db")
+	output, code = exec(append(configFlags, "--set", "db.dsn=file:"+dbFile, "routes")...)
+	a.Equal(exitOK, code)
+	a.Contains(output, "/users/:id")
+	_, err = os.Stat(filepath.Dir(dbFile))
+	a.True(os.IsNotExist(err))
+
+	output, code = exec("codes", "list")
+	a.Equal(exitOK, code)
+	a.Contains(output, "NOT_FOUND")
+}
+
+// setenv 设置环境变量，测试结束后恢复
+func setenv(t *testing.T, key, value string) {
+	old, ok := os.LookupEnv(key)
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if ok {
+			_ = os.Setenv(key, old)
+		} else {
+			_ = os.Unsetenv(key)
+		}
+	})
+}
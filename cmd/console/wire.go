@@ -0,0 +1,27 @@
+// +build wireinject
+
+package main
+
+import (
+	"github.com/google/wire"
+	"project/app"
+	"project/app/pkg/config"
+	"project/app/pkg/db"
+	"project/console"
+)
+
+func createConfigCmd(overrides config.Overrides, configFiles ...config.FilePath) (*console.ConfigCmd, func(), error) {
+	panic(wire.Build(app.ProviderSet, console.NewConfigCmd))
+}
+
+func createSmsCmd(overrides config.Overrides, configFiles ...config.FilePath) (*console.SmsCmd, func(), error) {
+	panic(wire.Build(app.ProviderSet, console.NewSmsCmd))
+}
+
+func createRoutesCmd(overrides config.Overrides, configFiles ...config.FilePath) (*console.RoutesCmd, func(), error) {
+	panic(wire.Build(app.CoreSet, db.NewConfig, db.NewUnconnectedDB, console.NewRoutesCmd))
+}
+
+func createDbCmd(overrides config.Overrides, configFiles ...config.FilePath) (*console.DbCmd, func(), error) {
+	panic(wire.Build(app.ProviderSet, console.NewDbCmd))
+}
@@ -0,0 +1,311 @@
+// Code generated by Wire. DO NOT EDIT.
+
+//go:generate go run github.com/google/wire/cmd/wire
+//+build !wireinject
+
+package main
+
+import (
+	"project/app"
+	"project/app/dao"
+	"project/app/handler"
+	"project/app/pkg/cache"
+	"project/app/pkg/config"
+	"project/app/pkg/db"
+	"project/app/pkg/health"
+	"project/app/pkg/logger"
+	"project/app/pkg/metrics"
+	"project/app/pkg/pagination"
+	"project/app/pkg/panicreport"
+	"project/app/pkg/redact"
+	"project/app/pkg/sms"
+	"project/app/pkg/tracing"
+	"project/app/service"
+	"project/console"
+)
+
+// Injectors from wire.go:
+
+func createConfigCmd(overrides config.Overrides, configFiles ...config.FilePath) (*console.ConfigCmd, func(), error) {
+	viper, err := app.NewViper(overrides, configFiles...)
+	if err != nil {
+		return nil, nil, err
+	}
+	redactor, err := redact.NewRedactor(viper)
+	if err != nil {
+		return nil, nil, err
+	}
+	configCmd := console.NewConfigCmd(viper, redactor)
+	return configCmd, func() {
+	}, nil
+}
+
+func createSmsCmd(overrides config.Overrides, configFiles ...config.FilePath) (*console.SmsCmd, func(), error) {
+	viper, err := app.NewViper(overrides, configFiles...)
+	if err != nil {
+		return nil, nil, err
+	}
+	aliyunConfig, err := sms.NewAliyunConfig(viper)
+	if err != nil {
+		return nil, nil, err
+	}
+	tracingConfig, err := tracing.NewConfig(viper)
+	if err != nil {
+		return nil, nil, err
+	}
+	isDebug := config.NewIsDebug(viper)
+	loggerConfig, err := logger.NewConfig(isDebug, viper)
+	if err != nil {
+		return nil, nil, err
+	}
+	levelController, err := logger.NewLevelController(loggerConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	zapLogger, cleanup, err := logger.NewZapLogger(isDebug, loggerConfig, levelController)
+	if err != nil {
+		return nil, nil, err
+	}
+	tracerProvider, cleanup2, err := tracing.NewTracerProvider(tracingConfig, zapLogger)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	aliyunLoginSms := sms.NewAliyunLoginSms(aliyunConfig, tracerProvider)
+	smsCmd := console.NewSmsCmd(aliyunLoginSms)
+	return smsCmd, func() {
+		cleanup2()
+		cleanup()
+	}, nil
+}
+
+func createRoutesCmd(overrides config.Overrides, configFiles ...config.FilePath) (*console.RoutesCmd, func(), error) {
+	viper, err := app.NewViper(overrides, configFiles...)
+	if err != nil {
+		return nil, nil, err
+	}
+	isDebug := config.NewIsDebug(viper)
+	listenerConfigs, err := app.NewListenerConfigs(viper)
+	if err != nil {
+		return nil, nil, err
+	}
+	adminConfig, err := handler.NewAdminConfig(viper)
+	if err != nil {
+		return nil, nil, err
+	}
+	adminListenerConfigs, err := app.NewAdminListenerConfigs(viper, adminConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	serverConfig, err := app.NewServerConfig(viper)
+	if err != nil {
+		return nil, nil, err
+	}
+	loggerConfig, err := logger.NewConfig(isDebug, viper)
+	if err != nil {
+		return nil, nil, err
+	}
+	levelController, err := logger.NewLevelController(loggerConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	zapLogger, cleanup, err := logger.NewZapLogger(isDebug, loggerConfig, levelController)
+	if err != nil {
+		return nil, nil, err
+	}
+	healthConfig, err := health.NewConfig(viper)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	cacheCache := cache.NewGoCache()
+	aliyunConfig, err := sms.NewAliyunConfig(viper)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	tracingConfig, err := tracing.NewConfig(viper)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	tracerProvider, cleanup2, err := tracing.NewTracerProvider(tracingConfig, zapLogger)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	aliyunLoginSms := sms.NewAliyunLoginSms(aliyunConfig, tracerProvider)
+	dbConfig, err := db.NewConfig(viper)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	sqlDB, cleanup3, err := db.NewUnconnectedDB(dbConfig)
+	if err != nil {
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	registry := app.NewHealthRegistry(healthConfig, cacheCache, aliyunLoginSms, sqlDB)
+	requestIdMiddleware := _wireRequestIdMiddlewareValue
+	redactor, err := redact.NewRedactor(viper)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	tracingMiddleware := handler.NewTracingMiddleware(tracerProvider, redactor)
+	bodyCaptureConfig, err := handler.NewBodyCaptureConfig(viper)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	loggerMiddleware := handler.NewLoggerMiddleware(zapLogger, redactor, bodyCaptureConfig)
+	metricsConfig, err := metrics.NewConfig(viper)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	prometheusRegistry, err := metrics.NewRegistry()
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	httpMetrics, err := metrics.NewHttpMetrics(metricsConfig, prometheusRegistry)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	metricsMiddleware := handler.NewMetricsMiddleware(httpMetrics)
+	panicreportConfig, err := panicreport.NewConfig(viper)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	logNotifier := panicreport.NewLogNotifier(zapLogger)
+	recorder, cleanup4, err := panicreport.NewRecorder(panicreportConfig, logNotifier, redactor, zapLogger)
+	if err != nil {
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	recoveryMiddleware := handler.NewRecoveryMiddleware(isDebug, zapLogger, recorder, redactor)
+	clientCertMiddleware := _wireClientCertMiddlewareValue
+	adminAuthMiddleware := handler.NewAdminAuthMiddleware(adminConfig)
+	loginSmsConfig, err := service.NewLoginSmsConfig(viper)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	smsMetrics, err := metrics.NewSmsMetrics(metricsConfig, prometheusRegistry)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	loginSmsService := service.NewLoginSmsService(loginSmsConfig, aliyunLoginSms, cacheCache, smsMetrics, tracerProvider, zapLogger)
+	loginSmsCtrl := handler.NewLoginSmsCtrl(loginSmsService)
+	logLevelCtrl := handler.NewLogLevelCtrl(levelController)
+	panicReportCtrl := handler.NewPanicReportCtrl(recorder)
+	healthCtrl := handler.NewHealthCtrl(registry)
+	metricsCtrl := handler.NewMetricsCtrl(prometheusRegistry)
+	reloadConfig, err := config.NewReloadConfig(viper)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	schema := app.NewConfigSchema()
+	reloader, cleanup5, err := app.NewConfigReloader(reloadConfig, viper, overrides, configFiles, schema, loggerConfig, levelController, loginSmsService, zapLogger)
+	if err != nil {
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	debugCtrl := handler.NewDebugCtrl(reloader, redactor)
+	configCtrl := handler.NewConfigCtrl(reloader)
+	userDao := dao.NewUserDao(sqlDB)
+	userService := service.NewUserService(userDao)
+	paginationConfig, err := pagination.NewConfig(viper)
+	if err != nil {
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+		return nil, nil, err
+	}
+	paginator := pagination.NewPaginator(paginationConfig)
+	userCtrl := handler.NewUserCtrl(userService, paginator)
+	appApp := app.NewApp(isDebug, listenerConfigs, adminListenerConfigs, serverConfig, zapLogger, registry, requestIdMiddleware, tracingMiddleware, loggerMiddleware, metricsMiddleware, recoveryMiddleware, clientCertMiddleware, adminAuthMiddleware, loginSmsCtrl, logLevelCtrl, panicReportCtrl, healthCtrl, metricsCtrl, debugCtrl, configCtrl, userCtrl)
+	routesCmd := console.NewRoutesCmd(appApp)
+	return routesCmd, func() {
+		cleanup5()
+		cleanup4()
+		cleanup3()
+		cleanup2()
+		cleanup()
+	}, nil
+}
+
+var (
+	_wireRequestIdMiddlewareValue  = &handler.RequestIdMiddleware{}
+	_wireClientCertMiddlewareValue = &handler.ClientCertMiddleware{}
+)
+
+func createDbCmd(overrides config.Overrides, configFiles ...config.FilePath) (*console.DbCmd, func(), error) {
+	viper, err := app.NewViper(overrides, configFiles...)
+	if err != nil {
+		return nil, nil, err
+	}
+	dbConfig, err := db.NewConfig(viper)
+	if err != nil {
+		return nil, nil, err
+	}
+	migrations := dao.NewMigrations()
+	isDebug := config.NewIsDebug(viper)
+	loggerConfig, err := logger.NewConfig(isDebug, viper)
+	if err != nil {
+		return nil, nil, err
+	}
+	levelController, err := logger.NewLevelController(loggerConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	zapLogger, cleanup, err := logger.NewZapLogger(isDebug, loggerConfig, levelController)
+	if err != nil {
+		return nil, nil, err
+	}
+	sqlDB, cleanup2, err := db.NewDB(dbConfig, migrations, zapLogger)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	dbCmd := console.NewDbCmd(sqlDB, migrations)
+	return dbCmd, func() {
+		cleanup2()
+		cleanup()
+	}, nil
+}
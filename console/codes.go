@@ -0,0 +1,23 @@
+package console
+
+import (
+	"io"
+	"project/app/handler/pkg/e"
+	"strconv"
+)
+
+// PrintCodes 输出所有业务错误码及其名称、http status、日志级别、描述
+func PrintCodes(w io.Writer) error {
+	details := e.CodeDetails()
+	rows := make([][]string, 0, len(details))
+	for _, detail := range details {
+		rows = append(rows, []string{
+			strconv.Itoa(int(detail.Code)),
+			detail.Status,
+			strconv.Itoa(detail.HttpStatus),
+			detail.LogLevel.String(),
+			detail.Message,
+		})
+	}
+	return printTable(w, []string{"CODE", "STATUS", "HTTP", "LOG_LEVEL", "MESSAGE"}, rows)
+}
@@ -0,0 +1,49 @@
+package console
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+	"io"
+	"project/app"
+	"project/app/pkg/config"
+	"project/app/pkg/redact"
+	"strings"
+)
+
+// CheckConfig 读取并校验配置（与 app 启动时相同），配置有误时返回 *config.ValidationError 列出所有问题
+func CheckConfig(w io.Writer, overrides config.Overrides, configFiles ...config.FilePath) error {
+	if _, err := app.NewViper(overrides, configFiles...); err != nil {
+		return err
+	}
+	files := make([]string, 0, len(configFiles))
+	for _, file := range configFiles {
+		files = append(files, string(file))
+	}
+	_, err := fmt.Fprintf(w, "config ok: %s\n", strings.Join(files, ", "))
+	return err
+}
+
+// ConfigCmd 查看配置
+type ConfigCmd struct {
+	v        *viper.Viper
+	redactor *redact.Redactor
+}
+
+func NewConfigCmd(v *viper.Viper, redactor *redact.Redactor) *ConfigCmd {
+	return &ConfigCmd{v: v, redactor: redactor}
+}
+
+// Print 以 yaml 格式输出合并所有配置文件、环境变量、命令行参数（已解密）后的配置，redacted 为 true 时机密配置项脱敏
+func (cmd *ConfigCmd) Print(w io.Writer, redacted bool) error {
+	settings := cmd.v.AllSettings()
+	if redacted {
+		settings = cmd.redactor.Settings(settings)
+	}
+	data, err := yaml.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
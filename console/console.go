@@ -0,0 +1,21 @@
+// 本包为应用程序 console 的子命令实现：配置检查及查看、测试短信发送、路由表、业务错误码、数据库迁移、加密配置值等。
+// 子命令依赖的组件与 app 共用 app.ProviderSet，由 cmd/console 中的 wire injector 实例化。
+
+package console
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// printTable 输出以空格对齐的表格，header 为表头
+func printTable(w io.Writer, header []string, rows [][]string) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
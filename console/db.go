@@ -0,0 +1,47 @@
+package console
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"project/app/pkg/db"
+)
+
+// DbCmd 数据库相关命令
+type DbCmd struct {
+	db         *sql.DB
+	migrations db.Migrations
+}
+
+func NewDbCmd(database *sql.DB, migrations db.Migrations) *DbCmd {
+	return &DbCmd{db: database, migrations: migrations}
+}
+
+// Migrate 依次执行未执行的迁移并输出，dryRun 为 true 时仅输出未执行的迁移
+func (cmd *DbCmd) Migrate(ctx context.Context, w io.Writer, dryRun bool) error {
+	var (
+		migrations []db.Migration
+		err        error
+	)
+	if dryRun {
+		migrations, err = db.Pending(ctx, cmd.db, cmd.migrations)
+	} else {
+		migrations, err = db.Migrate(ctx, cmd.db, cmd.migrations)
+	}
+	// 部分迁移执行成功后失败时，仍输出已执行的迁移
+	for _, m := range migrations {
+		if dryRun {
+			fmt.Fprintf(w, "pending %d_%s\n", m.Version, m.Name)
+		} else {
+			fmt.Fprintf(w, "applied %d_%s\n", m.Version, m.Name)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(migrations) == 0 {
+		fmt.Fprintln(w, "no pending migrations")
+	}
+	return nil
+}
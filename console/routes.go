@@ -0,0 +1,42 @@
+package console
+
+import (
+	"github.com/gin-gonic/gin"
+	"io"
+	"project/app"
+	"sort"
+)
+
+// RoutesCmd 查看路由表
+type RoutesCmd struct {
+	app *app.App
+}
+
+func NewRoutesCmd(app *app.App) *RoutesCmd {
+	return &RoutesCmd{app: app}
+}
+
+// Print 输出业务接口、管理接口的路由表（与管理接口 /admin/routes 相同），按监听地址、路径、方法排序
+func (cmd *RoutesCmd) Print(w io.Writer) error {
+	// 避免 gin 在 debug 模式下注册路由时输出调试信息
+	gin.SetMode(gin.ReleaseMode)
+	routes, err := cmd.app.Routes()
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, 0)
+	for listener, infos := range routes {
+		for _, info := range infos {
+			rows = append(rows, []string{listener, info.Method, info.Path, info.Handler})
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		for k := 0; k < 3; k++ {
+			if rows[i][k] != rows[j][k] {
+				return rows[i][k] < rows[j][k]
+			}
+		}
+		return false
+	})
+	return printTable(w, []string{"LISTENER", "METHOD", "PATH", "HANDLER"}, rows)
+}
@@ -0,0 +1,93 @@
+package console
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"project/app/pkg/config"
+	"project/app/pkg/secret"
+)
+
+// 加密配置值相关命令，密钥通过 config.SecretKeyEnv 环境变量提供
+
+// SecretKeygen 生成密钥
+func SecretKeygen(w io.Writer) error {
+	key, err := secret.GenerateKey()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, key)
+	return err
+}
+
+// SecretEncrypt 加密配置值
+func SecretEncrypt(w io.Writer, plaintext string) error {
+	keys, err := loadSecretKeys()
+	if err != nil {
+		return err
+	}
+	value, err := keys.Encrypt(plaintext)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, value)
+	return err
+}
+
+// SecretDecrypt 解密配置值
+func SecretDecrypt(w io.Writer, value string) error {
+	keys, err := loadSecretKeys()
+	if err != nil {
+		return err
+	}
+	plaintext, err := keys.Decrypt(value)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, plaintext)
+	return err
+}
+
+// SecretRotate 使用 config.SecretKeyEnv 中的密钥解密配置文件 file 中的所有密文，再使用新密钥 newKey 重新加密
+func SecretRotate(w io.Writer, file string, newKey string) error {
+	to, err := secret.ParseKeys(newKey)
+	if err != nil {
+		return errors.Wrap(err, "parse new key failed")
+	}
+	from, err := loadSecretKeys()
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return errors.Wrap(err, "stat config file failed")
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return errors.Wrap(err, "read config file failed")
+	}
+	rotated, count, err := secret.Rotate(string(data), from, to)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(file, []byte(rotated), info.Mode().Perm()); err != nil {
+		return errors.Wrap(err, "write config file failed")
+	}
+	_, err = fmt.Fprintf(w, "%d encrypted values rotated in %s\n", count, file)
+	return err
+}
+
+// loadSecretKeys 读取密钥，未设置时返回错误
+func loadSecretKeys() (secret.Keys, error) {
+	keys, err := config.LoadSecretKeys()
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		return nil, errors.Errorf("%s or %s_FILE is required", config.SecretKeyEnv, config.SecretKeyEnv)
+	}
+	return keys, nil
+}
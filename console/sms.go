@@ -0,0 +1,28 @@
+package console
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"project/app/pkg/sms"
+	"project/app/pkg/util"
+)
+
+// SmsCmd 短信服务相关命令
+type SmsCmd struct {
+	sender sms.Sender
+}
+
+func NewSmsCmd(sender sms.Sender) *SmsCmd {
+	return &SmsCmd{sender: sender}
+}
+
+// SendTest 向手机号 phone 发送一条随机测试验证码，用于检查短信服务配置（密钥、签名、模板）是否正确
+func (cmd *SmsCmd) SendTest(ctx context.Context, w io.Writer, phone string) error {
+	code := util.GenerateRandomDigits(6)
+	if err := cmd.sender.Send(ctx, phone, code, 5); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "test code %s sent to %s via %s\n", code, phone, cmd.sender.Provider())
+	return err
+}